@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineUpdateCmd)
+	baselineUpdateCmd.Flags().Bool("no-git", false, "treat git repo as a regular directory and scan those files, --log-opts has no effect on the scan when --no-git is set")
+}
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "manage baselines of known findings",
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "scan and rewrite the baseline with the current findings",
+	Long: `Scan the source and rewrite the baseline given by --baseline-path with
+every finding of the scan. Findings that were fixed are dropped from the
+baseline and findings whose location changed are recorded at their new
+location.`,
+	Run: runBaselineUpdate,
+}
+
+func runBaselineUpdate(cmd *cobra.Command, args []string) {
+	initConfig()
+
+	baselinePath, err := cmd.Flags().GetString("baseline-path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if baselinePath == "" {
+		log.Fatal().Msg("--baseline-path is required")
+	}
+
+	if !fileExists(baselinePath) {
+		// nothing to load when the baseline is created for the first time
+		if err := cmd.Flags().Set("baseline-path", ""); err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+	}
+
+	cfg := Config(cmd)
+	start := time.Now()
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	detector := Detector(cmd, cfg, source)
+	// every finding that is still present belongs in the new baseline, so
+	// don't filter the ones the old baseline already contains
+	detector.Baseline = nil
+
+	findings, err := scan(cmd, detector, source)
+	if err != nil {
+		log.Fatal().Err(err).Msg("scan failed, baseline not updated")
+	}
+	if err := report.Write(findings, cfg, "json", baselinePath); err != nil {
+		log.Fatal().Err(err).Msg("could not write baseline")
+	}
+	log.Info().Msgf("baseline updated with %d findings in %s", len(findings), FormatDuration(time.Since(start)))
+}
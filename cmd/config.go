@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configTestCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configPrintCmd)
+	configPrintCmd.Flags().String("format", "toml", "output format (toml, json)")
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "work with gitleaks configs",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [config]",
+	Short: "check a config for mistakes",
+	Long: `Check a config for mistakes like invalid regexes, rules without a regex
+or path, duplicate rule IDs, unknown allowlist regexTarget values and
+keywords that can never be part of a match. The config defaults to
+--config, GITLEAKS_CONFIG or the config file in --source.
+
+All problems are printed with their file and line. The command exits with
+code 1 if any problem is not a warning.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigValidate,
+}
+
+var configTestCmd = &cobra.Command{
+	Use:   "test [config]",
+	Short: "run the tests defined by the rules of a config",
+	Long: `Run the [[rules.tests]] blocks of a config. Every true positive must be
+detected by its rule, with the expected secret if one is set, and no false
+positive may be. Each rule is tested on its own with the global allowlist.
+The config defaults to the config a scan would use. --enable-rule,
+--disable-rule, --enable-tag, --disable-tag and --profile limit the rules
+that are tested.
+
+Failures are printed and the command exits with code 1 if there are any.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigTest,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "print the JSON Schema of config files",
+	Long: `Print the JSON Schema of gitleaks config files. Editors can use it to
+validate and complete .gitleaks.toml, .gitleaks.yaml and .gitleaks.json
+files.`,
+	Args: cobra.NoArgs,
+	Run:  runConfigSchema,
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print [config]",
+	Short: "print the effective config",
+	Long: `Print the config a scan would use, with extended configs merged in and
+the rules selected by --enable-rule, --disable-rule, --enable-tag,
+--disable-tag and --profile. The config defaults to the config a scan
+would use. Every rule is annotated with the config it came from.
+
+The output is a valid config in the format given by --format. Nested
+configs of subdirectories are not included.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigPrint,
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		path = configFilePath(cmd)
+	}
+
+	var (
+		problems []config.Problem
+		err      error
+	)
+	if path == "" {
+		log.Info().Msg("no gitleaks config found, validating the default config")
+		problems = config.Validate("default config", []byte(config.DefaultConfig))
+	} else if problems, err = config.ValidateFile(path); err != nil {
+		log.Fatal().Err(err).Msg("could not read config")
+	}
+
+	// load the config like a scan would to catch problems in extended
+	// configs
+	if path != "" && !config.HasErrors(problems) {
+		if _, err := config.LoadFile(path); err != nil {
+			problems = append(problems, config.Problem{File: path, Message: err.Error()})
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if config.HasErrors(problems) {
+		os.Exit(1)
+	}
+	if len(problems) == 0 {
+		log.Info().Msgf("no problems found in %s", path)
+	}
+}
+
+// configFilePath returns the config file a scan would use, or an empty
+// string if the default config would be used.
+func configFilePath(cmd *cobra.Command) string {
+	if cfgPath, _ := cmd.Flags().GetString("config"); cfgPath != "" {
+		return cfgPath
+	}
+	if envPath := os.Getenv("GITLEAKS_CONFIG"); envPath != "" {
+		return envPath
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	return config.FindFile(source)
+}
+
+func runConfigPrint(cmd *cobra.Command, args []string) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	cfg := selectedConfig(cmd, args)
+	switch format {
+	case "toml":
+		err = cfg.WriteTOML(os.Stdout)
+	case "json":
+		err = cfg.WriteJSON(os.Stdout)
+	default:
+		log.Fatal().Msgf("unknown format %s, expected toml or json", format)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not print config")
+	}
+}
+
+// selectedConfig loads the config given as argument, or the config a scan
+// would use, and removes the rules that are not selected.
+func selectedConfig(cmd *cobra.Command, args []string) config.Config {
+	var cfg config.Config
+	if len(args) == 1 {
+		var err error
+		if cfg, err = config.LoadFile(args[0]); err != nil {
+			log.Fatal().Err(err).Msg("Failed to load config")
+		}
+	} else {
+		initConfig()
+		cfg = Config(cmd)
+	}
+
+	if selection := ruleSelection(cmd, cfg); !selection.Empty() {
+		if err := selection.Validate(cfg); err != nil {
+			log.Fatal().Msgf("Requested %s", err)
+		}
+		cfg.FilterRules(selection.Selects)
+	}
+	return cfg
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not generate schema")
+	}
+	if _, err := os.Stdout.Write(schema); err != nil {
+		log.Fatal().Err(err).Msg("could not write schema")
+	}
+}
+
+func runConfigTest(cmd *cobra.Command, args []string) {
+	cfg := selectedConfig(cmd, args)
+	result := detect.RunRuleTests(cfg)
+	for _, f := range result.Failures {
+		fmt.Println(f)
+	}
+	if result.Rules == 0 {
+		log.Warn().Msg("no rule tests found")
+	} else {
+		log.Info().Msgf("%d rules tested, %d cases, %d failures", result.Rules, result.Cases, len(result.Failures))
+	}
+	if len(result.Failures) != 0 {
+		os.Exit(1)
+	}
+}
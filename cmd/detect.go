@@ -7,6 +7,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/zricethezav/gitleaks/v8/detect"
 	"github.com/zricethezav/gitleaks/v8/report"
 	"github.com/zricethezav/gitleaks/v8/sources"
 )
@@ -49,6 +50,17 @@ func runDetect(cmd *cobra.Command, args []string) {
 		log.Fatal().Err(err).Msg("could not get exit code")
 	}
 
+	streams := streamReports(cmd, detector)
+	findings, err = scan(cmd, detector, source)
+
+	writeSuppressions(cmd, detector)
+	writeStats(cmd, detector)
+	findingSummaryAndExit(findings, cmd, cfg, streams, exitCode, start, err)
+}
+
+// scan runs the type of scan selected by the command's flags and returns
+// its findings.
+func scan(cmd *cobra.Command, detector *detect.Detector, source string) ([]report.Finding, error) {
 	// determine what type of scan:
 	// - git: scan the history of the repo
 	// - no-git: scan files by treating the repo as a plain directory
@@ -56,45 +68,49 @@ func runDetect(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("could not call GetBool() for no-git")
 	}
-	fromPipe, err := cmd.Flags().GetBool("pipe")
-	if err != nil {
-		log.Fatal().Err(err)
+	// the pipe flag is only defined by commands that can read from stdin
+	fromPipe := false
+	if cmd.Flags().Lookup("pipe") != nil {
+		if fromPipe, err = cmd.Flags().GetBool("pipe"); err != nil {
+			log.Fatal().Err(err)
+		}
 	}
 
-	// start the detector scan
 	if noGit {
 		paths, err := sources.FilesystemTargets(source, detector.Sema, detector.FollowSymlinks)
 		if err != nil {
 			log.Fatal().Err(err)
 		}
-		findings, err = detector.DetectFiles(paths)
+		findings, err := detector.DetectFiles(paths)
 		if err != nil {
 			// don't exit on error, just log it
 			log.Error().Err(err).Msg("")
 		}
-	} else if fromPipe {
-		findings, err = detector.DetectReader(os.Stdin, 10)
+		return findings, err
+	}
+
+	if fromPipe {
+		findings, err := detector.DetectReader(os.Stdin, 10)
 		if err != nil {
 			// log fatal to exit, no need to continue since a report
 			// will not be generated when scanning from a pipe...for now
 			log.Fatal().Err(err).Msg("")
 		}
-	} else {
-		var logOpts string
-		logOpts, err = cmd.Flags().GetString("log-opts")
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
-		}
-		gitCmd, err := sources.NewGitLogCmd(source, logOpts)
-		if err != nil {
-			log.Fatal().Err(err).Msg("")
-		}
-		findings, err = detector.DetectGit(gitCmd)
-		if err != nil {
-			// don't exit on error, just log it
-			log.Error().Err(err).Msg("")
-		}
+		return findings, err
 	}
 
-	findingSummaryAndExit(findings, cmd, cfg, exitCode, start, err)
+	logOpts, err := cmd.Flags().GetString("log-opts")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	gitCmd, err := sources.NewGitLogCmd(source, logOpts)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	findings, err := detector.DetectGit(gitCmd)
+	if err != nil {
+		// don't exit on error, just log it
+		log.Error().Err(err).Msg("")
+	}
+	return findings, err
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().String("rule", "", "id of the rule to explain")
+	explainCmd.Flags().String("path", "", "path of the input for path rules and allowlists, defaults to the file argument")
+	explainCmd.Flags().String("commit", "", "commit of the input for commit allowlists")
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain --rule <id> [file]",
+	Short: "explain why a rule reports a match or not",
+	Long: `Scan a file, or stdin if no file or - is given, and print every regex
+match of the rule given by --rule with the checks that decided whether it
+is reported: the keyword prefilter, the rule's path, the allowlists with
+the part of the match their regexes target and their stopwords, the
+entropy against the rule's threshold, the digit check of generic rules,
+gitleaks:allow comments and the deduplication of generic findings.
+
+All rules of the config are run so generic findings are deduplicated like
+in a scan. Secrets are printed as they are, .gitleaksignore entries and
+baselines are not applied.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) {
+	initConfig()
+	cfg := Config(cmd)
+
+	ruleID, err := cmd.Flags().GetString("rule")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if ruleID == "" {
+		log.Fatal().Msg("--rule is required")
+	}
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	commit, err := cmd.Flags().GetString("commit")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	var raw []byte
+	if len(args) == 0 || args[0] == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(args[0])
+		if path == "" {
+			path = args[0]
+		}
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not read input")
+	}
+
+	detector := detect.NewDetector(cfg)
+	if detector.MaxTargetMegaBytes, err = cmd.Flags().GetInt("max-target-megabytes"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if detector.IgnoreGitleaksAllow, err = cmd.Flags().GetBool("ignore-gitleaks-allow"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	explanation, err := detector.Explain(detect.Fragment{
+		Raw:       string(raw),
+		FilePath:  path,
+		CommitSHA: commit,
+	}, ruleID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	fmt.Print(explanation)
+}
@@ -20,10 +20,12 @@ func SumoLogicAccessID() *config.Rule {
 		Keywords: []string{
 			"sumo",
 		},
-		Allowlist: config.Allowlist{
-			RegexTarget: "line",
-			Regexes: []*regexp.Regexp{
-				regexp.MustCompile(`sumOf`),
+		Allowlists: []config.Allowlist{
+			{
+				RegexTarget: "line",
+				Regexes: []*regexp.Regexp{
+					regexp.MustCompile(`sumOf`),
+				},
 			},
 		},
 	}
@@ -37,6 +37,8 @@ func runProtect(cmd *cobra.Command, args []string) {
 	start := time.Now()
 	detector := Detector(cmd, cfg, source)
 
+	streams := streamReports(cmd, detector)
+
 	// start git scan
 	var findings []report.Finding
 	gitCmd, err := sources.NewGitDiffCmd(source, staged)
@@ -45,5 +47,7 @@ func runProtect(cmd *cobra.Command, args []string) {
 	}
 	findings, err = detector.DetectGit(gitCmd)
 
-	findingSummaryAndExit(findings, cmd, cfg, exitCode, start, err)
+	writeSuppressions(cmd, detector)
+	writeStats(cmd, detector)
+	findingSummaryAndExit(findings, cmd, cfg, streams, exitCode, start, err)
 }
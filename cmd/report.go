@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportDiffCmd)
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "work with gitleaks reports",
+}
+
+var reportDiffCmd = &cobra.Command{
+	Use:   "diff <old-report> <new-report>",
+	Short: "compare two reports and write the newly introduced findings",
+	Long: `Compare two reports and classify the findings of the new report as new,
+fixed or unchanged. Findings are matched by rule, file and secret, so a
+finding that moved to another line is unchanged. Reports can be in json,
+csv or sarif format.
+
+The new findings are written to the reports given by --report-path and
+--report and the command exits with --exit-code if there are any.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runReportDiff,
+}
+
+func runReportDiff(cmd *cobra.Command, args []string) {
+	initConfig()
+	cfg := Config(cmd)
+	// fail on invalid reports before reading the others
+	reportOutputs(cmd)
+
+	oldFindings := readReport(args[0])
+	newFindings := readReport(args[1])
+	diff := report.DiffFindings(oldFindings, newFindings)
+
+	// the summary goes to the log so reports written to stdout stay valid
+	log.Info().Msgf("%d new, %d fixed, %d unchanged findings", len(diff.New), len(diff.Fixed), len(diff.Unchanged))
+
+	writeReports(cmd, diff.New, cfg, reportStreams{})
+
+	exitCode, err := cmd.Flags().GetInt("exit-code")
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not get exit code")
+	}
+	if len(diff.New) != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+func readReport(path string) []report.Finding {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not read report")
+	}
+	findings, err := report.Read(data)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("could not read report %s", path)
+	}
+	return findings
+}
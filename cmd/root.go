@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +18,7 @@ import (
 
 	"github.com/zricethezav/gitleaks/v8/config"
 	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/metrics"
 	"github.com/zricethezav/gitleaks/v8/report"
 )
 
@@ -30,7 +35,7 @@ const configDescription = `config file path
 order of precedence:
 1. --config/-c
 2. env var GITLEAKS_CONFIG
-3. (--source/-s)/.gitleaks.toml
+3. (--source/-s)/.gitleaks.toml, .gitleaks.yaml, .gitleaks.yml or .gitleaks.json
 If none of the three options are used, then gitleaks will use the default config`
 
 var rootCmd = &cobra.Command{
@@ -43,21 +48,36 @@ func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "", configDescription)
 	rootCmd.PersistentFlags().Int("exit-code", 1, "exit code when leaks have been encountered")
 	rootCmd.PersistentFlags().StringP("source", "s", ".", "path to source")
-	rootCmd.PersistentFlags().StringP("report-path", "r", "", "report file")
-	rootCmd.PersistentFlags().StringP("report-format", "f", "json", "output format (json, csv, junit, sarif)")
+	rootCmd.PersistentFlags().StringP("report-path", "r", "", "report file, use - to write the report to stdout")
+	rootCmd.PersistentFlags().StringP("report-format", "f", "json", "output format (json, jsonl, csv, junit, sarif, gitlab, html, template)")
+	rootCmd.PersistentFlags().StringArray("report", []string{}, "additional report as format:path, can be repeated, ex: `--report sarif:gitleaks.sarif --report junit:gitleaks.xml`")
+	rootCmd.PersistentFlags().String("report-template", "", "text/template file used to render the report when --report-format is template")
+	rootCmd.PersistentFlags().String("report-repo-url", "", "web page of the repository commits link to in html reports, defaults to the origin remote of --source, ex: https://github.com/zricethezav/gitleaks")
 	rootCmd.PersistentFlags().StringP("baseline-path", "b", "", "path to baseline with issues that can be ignored")
+	rootCmd.PersistentFlags().Bool("baseline-ignore-location", false, "match baseline findings by rule, file and secret only, so findings whose line moved are not reported again")
 	rootCmd.PersistentFlags().StringP("log-level", "l", "info", "log level (trace, debug, info, warn, error, fatal)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "show verbose output from scan")
 	rootCmd.PersistentFlags().BoolP("no-color", "", false, "turn off color for verbose output")
 	rootCmd.PersistentFlags().Int("max-target-megabytes", 0, "files larger than this will be skipped")
 	rootCmd.PersistentFlags().BoolP("ignore-gitleaks-allow", "", false, "ignore gitleaks:allow comments")
+	rootCmd.PersistentFlags().String("suppressions-path", "", "write findings suppressed by gitleaks:allow comments or allowlists with a description, with their reasons, to this json file")
 	rootCmd.PersistentFlags().Uint("redact", 0, "redact secrets from logs and stdout. To redact only parts of the secret just apply a percent value from 0..100. For example --redact=20 (default 100%)")
 	rootCmd.Flag("redact").NoOptDefVal = "100"
+	rootCmd.PersistentFlags().Bool("secret-hash", false, "record the SHA-256 of secrets in reports, so redacted reports can be used as baselines. The hash reveals short or guessable secrets")
 	rootCmd.PersistentFlags().Bool("no-banner", false, "suppress banner")
 	rootCmd.PersistentFlags().String("log-opts", "", "git log options")
 	rootCmd.PersistentFlags().StringSlice("enable-rule", []string{}, "only enable specific rules by id, ex: `gitleaks detect --enable-rule=atlassian-api-token --enable-rule=slack-access-token`")
+	rootCmd.PersistentFlags().StringSlice("disable-rule", []string{}, "disable specific rules by id, ex: `gitleaks detect --disable-rule=generic-api-key`")
+	rootCmd.PersistentFlags().StringSlice("enable-tag", []string{}, "only enable rules with one of these tags, ex: `gitleaks detect --enable-tag=aws --enable-tag=gcp`")
+	rootCmd.PersistentFlags().StringSlice("disable-tag", []string{}, "disable rules with one of these tags")
+	rootCmd.PersistentFlags().String("profile", "", "use the rule selection, redaction and exit code of a profile defined in the config, ex: `gitleaks detect --profile=ci`")
 	rootCmd.PersistentFlags().StringP("gitleaks-ignore-path", "i", ".", "path to .gitleaksignore file or folder containing one")
 	rootCmd.PersistentFlags().Bool("follow-symlinks", false, "scan files that are symlinks to other files")
+	rootCmd.PersistentFlags().Bool("stats", false, "print scan statistics, like the time spent in each rule and keyword hit rates, to stderr")
+	rootCmd.PersistentFlags().String("stats-path", "", "write scan statistics as json to this file")
+	rootCmd.PersistentFlags().String("metrics-textfile", "", "write prometheus metrics to this file when the scan completes, ex: for the node exporter textfile collector")
+	rootCmd.PersistentFlags().Bool("verify", false, "check whether secrets are live using the verify requests defined by rules")
+	rootCmd.PersistentFlags().String("verify-endpoint-override", "", "send verify requests to this base URL instead of the one defined by the rule, ex: http://localhost:8080")
 	err := viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
 	if err != nil {
 		log.Fatal().Msgf("err binding config %s", err.Error())
@@ -127,20 +147,17 @@ func initConfig() {
 			return
 		}
 
-		if _, err := os.Stat(filepath.Join(source, ".gitleaks.toml")); os.IsNotExist(err) {
-			log.Debug().Msgf("no gitleaks config found in path %s, using default gitleaks config", filepath.Join(source, ".gitleaks.toml"))
+		cfgPath = config.FindFile(source)
+		if cfgPath == "" {
+			log.Debug().Msgf("no gitleaks config found in path %s, using default gitleaks config", source)
 			viper.SetConfigType("toml")
 			if err = viper.ReadConfig(strings.NewReader(config.DefaultConfig)); err != nil {
 				log.Fatal().Msgf("err reading default config toml %s", err.Error())
 			}
 			return
-		} else {
-			log.Debug().Msgf("using existing gitleaks config %s from `(--source)/.gitleaks.toml`", filepath.Join(source, ".gitleaks.toml"))
 		}
-
-		viper.AddConfigPath(source)
-		viper.SetConfigName(".gitleaks")
-		viper.SetConfigType("toml")
+		log.Debug().Msgf("using existing gitleaks config %s from --source", cfgPath)
+		viper.SetConfigFile(cfgPath)
 	}
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatal().Msgf("unable to load gitleaks config, err: %s", err)
@@ -162,15 +179,70 @@ func Config(cmd *cobra.Command) config.Config {
 	if err := viper.Unmarshal(&vc); err != nil {
 		log.Fatal().Err(err).Msg("Failed to load config")
 	}
-	cfg, err := vc.Translate()
+	source := viper.ConfigFileUsed()
+	if source == "" {
+		source = config.DefaultSource
+	}
+	cfg, err := vc.TranslateFrom(source)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load config")
 	}
 	cfg.Path, _ = cmd.Flags().GetString("config")
 
+	// settings of the profile apply unless the flag was set
+	if p, ok := profile(cmd, cfg); ok {
+		if p.Redact != nil && !cmd.Flags().Changed("redact") {
+			if err := cmd.Flags().Set("redact", strconv.FormatUint(uint64(*p.Redact), 10)); err != nil {
+				log.Fatal().Err(err).Msg("could not apply profile")
+			}
+		}
+		if p.ExitCode != nil && !cmd.Flags().Changed("exit-code") {
+			if err := cmd.Flags().Set("exit-code", strconv.Itoa(*p.ExitCode)); err != nil {
+				log.Fatal().Err(err).Msg("could not apply profile")
+			}
+		}
+	}
+
 	return cfg
 }
 
+// profile returns the profile selected with --profile, if any.
+func profile(cmd *cobra.Command, cfg config.Config) (config.Profile, bool) {
+	name, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if name == "" {
+		return config.Profile{}, false
+	}
+	p, err := cfg.Profile(name)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	return p, true
+}
+
+// ruleSelection returns the rules selected by the profile and the rule and
+// tag flags.
+func ruleSelection(cmd *cobra.Command, cfg config.Config) config.RuleSelection {
+	var selection config.RuleSelection
+	for flag, values := range map[string]*[]string{
+		"enable-rule":  &selection.EnableRules,
+		"disable-rule": &selection.DisableRules,
+		"enable-tag":   &selection.EnableTags,
+		"disable-tag":  &selection.DisableTags,
+	} {
+		var err error
+		if *values, err = cmd.Flags().GetStringSlice(flag); err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+	}
+	if p, ok := profile(cmd, cfg); ok {
+		selection = p.RuleSelection.Override(selection)
+	}
+	return selection
+}
+
 func Detector(cmd *cobra.Command, cfg config.Config, source string) *detect.Detector {
 	var err error
 
@@ -192,8 +264,11 @@ func Detector(cmd *cobra.Command, cfg config.Config, source string) *detect.Dete
 		log.Fatal().Err(err).Msg("")
 	}
 
-	// if config path is not set, then use the {source}/.gitleaks.toml path.
-	// note that there may not be a `{source}/.gitleaks.toml` file, this is ok.
+	// if config path is not set, then use the config file in {source}.
+	// note that there may not be a config file in {source}, this is ok.
+	if detector.Config.Path == "" {
+		detector.Config.Path = config.FindFile(source)
+	}
 	if detector.Config.Path == "" {
 		detector.Config.Path = filepath.Join(source, ".gitleaks.toml")
 	}
@@ -205,6 +280,9 @@ func Detector(cmd *cobra.Command, cfg config.Config, source string) *detect.Dete
 	if detector.Redact, err = cmd.Flags().GetUint("redact"); err != nil {
 		log.Fatal().Err(err).Msg("")
 	}
+	if detector.HashSecrets, err = cmd.Flags().GetBool("secret-hash"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
 	if detector.MaxTargetMegaBytes, err = cmd.Flags().GetInt("max-target-megabytes"); err != nil {
 		log.Fatal().Err(err).Msg("")
 	}
@@ -236,38 +314,84 @@ func Detector(cmd *cobra.Command, cfg config.Config, source string) *detect.Dete
 		}
 	}
 
+	// load the config and .gitleaksignore files of subdirectories.
+	// git scans report paths relative to the repository while --no-git
+	// scans report paths that include the source.
+	noGit, pipe := false, false
+	if cmd.Flags().Lookup("no-git") != nil {
+		noGit, _ = cmd.Flags().GetBool("no-git")
+	}
+	if cmd.Flags().Lookup("pipe") != nil {
+		pipe, _ = cmd.Flags().GetBool("pipe")
+	}
+	if !pipe {
+		if err := detector.LoadNestedConfigs(source, !noGit); err != nil {
+			log.Fatal().Err(err).Msg("could not load nested configs")
+		}
+	}
+
 	// ignore findings from the baseline (an existing report in json format generated earlier)
 	baselinePath, _ := cmd.Flags().GetString("baseline-path")
+	if detector.BaselineIgnoreLocation, err = cmd.Flags().GetBool("baseline-ignore-location"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
 	if baselinePath != "" {
 		err = detector.AddBaseline(baselinePath, source)
 		if err != nil {
-			log.Error().Msgf("Could not load baseline. The path must point of a gitleaks report generated using the default format: %s", err)
+			log.Error().Msgf("Could not load baseline. The path must point to a gitleaks report in json, csv or sarif format: %s", err)
 		}
 	}
 
-	// If set, only apply rules that are defined in the flag
-	rules, _ := cmd.Flags().GetStringSlice("enable-rule")
-	if len(rules) > 0 {
-		log.Info().Msg("Overriding enabled rules: " + strings.Join(rules, ", "))
-		ruleOverride := make(map[string]config.Rule)
-		for _, ruleName := range rules {
-			if rule, ok := cfg.Rules[ruleName]; ok {
-				ruleOverride[ruleName] = rule
-			} else {
-				log.Fatal().Msgf("Requested rule %s not found in rules", ruleName)
-			}
+	// If set, only apply rules that are selected by the profile and flags
+	selection := ruleSelection(cmd, cfg)
+	if !selection.Empty() {
+		if err := selection.Validate(cfg); err != nil {
+			log.Fatal().Msgf("Requested %s", err)
+		}
+		if len(selection.EnableRules) > 0 {
+			log.Info().Msg("Overriding enabled rules: " + strings.Join(selection.EnableRules, ", "))
 		}
-		detector.Config.Rules = ruleOverride
+		detector.FilterRules(selection.Selects)
+		log.Debug().Msgf("%d rules selected", len(detector.Config.Rules))
 	}
 
 	// set follow symlinks flag
 	if detector.FollowSymlinks, err = cmd.Flags().GetBool("follow-symlinks"); err != nil {
 		log.Fatal().Err(err).Msg("")
 	}
+
+	// set up live secret verification
+	verify, err := cmd.Flags().GetBool("verify")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if verify {
+		endpointOverride, err := cmd.Flags().GetString("verify-endpoint-override")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		detector.Verifier = detect.NewHTTPVerifier(endpointOverride)
+	}
+
+	// collect scan statistics
+	stats, err := cmd.Flags().GetBool("stats")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	statsPath, err := cmd.Flags().GetString("stats-path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if stats || statsPath != "" {
+		detector.Stats = detect.NewStats()
+	}
+
 	return detector
 }
 
-func findingSummaryAndExit(findings []report.Finding, cmd *cobra.Command, cfg config.Config, exitCode int, start time.Time, err error) {
+func findingSummaryAndExit(findings []report.Finding, cmd *cobra.Command, cfg config.Config, streams reportStreams, exitCode int, start time.Time, err error) {
+	recordScanMetrics(cmd, start, err)
+
 	if err == nil {
 		log.Info().Msgf("scan completed in %s", FormatDuration(time.Since(start)))
 		if len(findings) != 0 {
@@ -285,22 +409,227 @@ func findingSummaryAndExit(findings []report.Finding, cmd *cobra.Command, cfg co
 	}
 
 	// write report if desired
+	writeReports(cmd, findings, cfg, streams)
+
+	if err != nil {
+		os.Exit(1)
+	}
+
+	if len(findings) != 0 {
+		os.Exit(exitCode)
+	}
+
+}
+
+// reportOutput is a report format and the path the report is written to.
+type reportOutput struct {
+	format string
+	path   string
+}
+
+// reportOutputs returns the reports requested by --report-path and
+// --report-format and by every --report format:path pair.
+func reportOutputs(cmd *cobra.Command) []reportOutput {
+	var outputs []reportOutput
 	reportPath, _ := cmd.Flags().GetString("report-path")
 	ext, _ := cmd.Flags().GetString("report-format")
 	if reportPath != "" {
-		if err := report.Write(findings, cfg, ext, reportPath); err != nil {
-			log.Fatal().Err(err).Msg("could not write")
+		format, err := report.Format(ext)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid --report-format")
 		}
+		outputs = append(outputs, reportOutput{format: format, path: reportPath})
 	}
 
+	reports, err := cmd.Flags().GetStringArray("report")
 	if err != nil {
-		os.Exit(1)
+		log.Fatal().Err(err).Msg("")
+	}
+	for _, r := range reports {
+		name, path, ok := strings.Cut(r, ":")
+		if !ok || name == "" || path == "" {
+			log.Fatal().Msgf("invalid --report %q, expected format:path", r)
+		}
+		format, err := report.Format(name)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("invalid --report %q", r)
+		}
+		outputs = append(outputs, reportOutput{format: format, path: path})
 	}
 
-	if len(findings) != 0 {
-		os.Exit(exitCode)
+	paths := make(map[string]bool)
+	for _, o := range outputs {
+		if paths[o.path] {
+			log.Fatal().Msgf("more than one report is written to %s", o.path)
+		}
+		paths[o.path] = true
+		if o.format == "template" {
+			if templatePath, _ := cmd.Flags().GetString("report-template"); templatePath == "" {
+				log.Fatal().Msg("--report-template is required for template reports")
+			}
+		}
 	}
+	return outputs
+}
+
+// reportStreams are the jsonl reports findings are written to while the
+// scan is running, keyed by report path.
+type reportStreams map[string]io.WriteCloser
+
+// streamReports writes findings to jsonl reports as soon as they are found.
+// The reports are validated before the scan starts.
+func streamReports(cmd *cobra.Command, detector *detect.Detector) reportStreams {
+	streams := reportStreams{}
+	var writers []*report.JsonlWriter
+	for _, o := range reportOutputs(cmd) {
+		if o.format != "jsonl" {
+			continue
+		}
+		if o.path == report.StdoutPath && detector.Verbose {
+			log.Warn().Msg("verbose output is mixed with the report when writing the report to stdout")
+		}
+
+		w, err := report.Create(o.path)
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not create report")
+		}
+		if o.path != report.StdoutPath {
+			// the report grows while scanning, don't scan it
+			if err := detector.ExcludePath(o.path); err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+		}
+		writers = append(writers, report.NewJsonlWriter(w))
+		streams[o.path] = w
+	}
+	if len(writers) == 0 {
+		return streams
+	}
+
+	detector.OnFinding = func(f report.Finding) {
+		for _, jw := range writers {
+			if err := jw.Write(f); err != nil {
+				log.Error().Err(err).Msg("could not write finding to report")
+			}
+		}
+	}
+	return streams
+}
+
+// writeReports writes findings to every requested report. Reports in
+// streams were written while scanning and are closed.
+func writeReports(cmd *cobra.Command, findings []report.Finding, cfg config.Config, streams reportStreams) {
+	for _, o := range reportOutputs(cmd) {
+		if w, ok := streams[o.path]; ok {
+			// findings were written while scanning
+			if err := w.Close(); err != nil {
+				log.Fatal().Err(err).Msgf("could not write %s", o.path)
+			}
+			continue
+		}
+
+		var err error
+		if o.format == "template" {
+			templatePath, _ := cmd.Flags().GetString("report-template")
+			err = report.WriteTemplate(findings, templatePath, o.path)
+		} else if o.format == "html" {
+			err = report.WriteHtml(findings, repositoryURL(cmd), o.path)
+		} else {
+			err = report.Write(findings, cfg, o.format, o.path)
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msgf("could not write %s", o.path)
+		}
+	}
+}
 
+// repositoryURL returns the web page of the scanned repository, given by
+// --report-repo-url or derived from the origin remote of --source.
+func repositoryURL(cmd *cobra.Command) string {
+	repoURL, err := cmd.Flags().GetString("report-repo-url")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if repoURL != "" {
+		return repoURL
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	remote, err := exec.Command("git", "-C", source, "remote", "get-url", "origin").Output()
+	if err != nil {
+		log.Debug().Err(err).Msg("no origin remote, commits link to the commit table of the html report")
+		return ""
+	}
+	return report.RepositoryURL(string(remote))
+}
+
+// recordScanMetrics records a completed scan and writes the metrics to
+// --metrics-textfile if set.
+func recordScanMetrics(cmd *cobra.Command, start time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	metrics.Scans.Inc(status)
+	metrics.ScanDuration.Observe(time.Since(start).Seconds())
+
+	textfile, _ := cmd.Flags().GetString("metrics-textfile")
+	if textfile == "" {
+		return
+	}
+	if err := metrics.WriteTextfile(textfile); err != nil {
+		log.Error().Err(err).Msg("could not write metrics")
+	}
+}
+
+// writeStats prints the scan statistics and writes them to --stats-path
+// if statistics were collected.
+func writeStats(cmd *cobra.Command, detector *detect.Detector) {
+	stats := detector.ScanStats()
+	if stats == nil {
+		return
+	}
+	if printStats, _ := cmd.Flags().GetBool("stats"); printStats {
+		if err := stats.WriteTable(os.Stderr); err != nil {
+			log.Error().Err(err).Msg("could not print stats")
+		}
+	}
+
+	statsPath, _ := cmd.Flags().GetString("stats-path")
+	if statsPath == "" {
+		return
+	}
+	file, err := os.Create(statsPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not write stats")
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", " ")
+	if err := encoder.Encode(stats); err != nil {
+		log.Fatal().Err(err).Msg("could not write stats")
+	}
+}
+
+// writeSuppressions writes the findings suppressed by gitleaks:allow
+// directives and described allowlists if a suppressions path was given.
+func writeSuppressions(cmd *cobra.Command, detector *detect.Detector) {
+	suppressionsPath, err := cmd.Flags().GetString("suppressions-path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	suppressions := detector.Suppressions()
+	if len(suppressions) != 0 {
+		log.Info().Msgf("%d findings suppressed by gitleaks:allow or allowlists", len(suppressions))
+	}
+	if suppressionsPath == "" {
+		return
+	}
+	if err := report.WriteSuppressions(suppressions, suppressionsPath); err != nil {
+		log.Fatal().Err(err).Msg("could not write suppressions")
+	}
 }
 
 func fileExists(fileName string) bool {
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/triage"
+)
+
+// defaultTriageBaseline is the baseline accepted risks are written to
+// when --baseline-path is not set.
+const defaultTriageBaseline = "gitleaks-baseline.json"
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+	triageCmd.Flags().String("state", "", "file the decisions are saved to, defaults to the report path with a .triage.json extension")
+	triageCmd.Flags().Int("context", 3, "number of lines shown before and after a finding")
+	triageCmd.Flags().Bool("all", false, "walk through all findings, including the ones that have a decision")
+}
+
+var triageCmd = &cobra.Command{
+	Use:   "triage <report>",
+	Short: "review the findings of a report one by one",
+	Long: `Walk through the findings of a report on the full screen of the
+terminal. Each finding is shown with the lines around it from the file,
+or from the commit for git scans of --source. Decide what to do with it
+by pressing a key:
+
+  f    false positive, written to the .gitleaksignore file in
+       --gitleaks-ignore-path
+  a    accepted risk, written to the json baseline in --baseline-path
+       (default ` + defaultTriageBaseline + `)
+  t    to fix, only recorded
+  →/s  skip the finding for now
+  ←/p  go back to the previous finding
+  q    quit
+
+False positives and accepted risks ask for an optional reason, type it
+and press Enter to save the decision or Esc to cancel.
+
+Decisions are saved to --state after every answer, running the command
+again resumes with the findings that have no decision. Use --all to
+review the findings that have one. Changing the decision of a finding
+updates .gitleaksignore and the baseline.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTriage,
+}
+
+func runTriage(cmd *cobra.Command, args []string) {
+	reportPath := args[0]
+	findings := readReport(reportPath)
+
+	statePath, err := cmd.Flags().GetString("state")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if statePath == "" {
+		statePath = strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".triage.json"
+	}
+	contextLines, err := cmd.Flags().GetInt("context")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	ignorePath, err := cmd.Flags().GetString("gitleaks-ignore-path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if info, err := os.Stat(ignorePath); err == nil && info.IsDir() {
+		ignorePath = filepath.Join(ignorePath, ".gitleaksignore")
+	}
+	baselinePath, err := cmd.Flags().GetString("baseline-path")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if baselinePath == "" {
+		baselinePath = defaultTriageBaseline
+	}
+	noColor, err := cmd.Flags().GetBool("no-color")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	state, err := triage.Load(statePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if len(findings) == 0 {
+		log.Info().Msg("the report has no findings")
+		return
+	}
+
+	opts := triage.Options{
+		StatePath:    statePath,
+		IgnorePath:   ignorePath,
+		BaselinePath: baselinePath,
+		Source:       source,
+		Context:      contextLines,
+		All:          all,
+		NoColor:      noColor,
+	}
+	if err := triage.Run(state, findings, opts); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	counts := make(map[triage.Decision]int)
+	for _, f := range findings {
+		if entry, ok := state.Lookup(f); ok {
+			counts[entry.Decision]++
+		}
+	}
+	undecided := len(findings) - counts[triage.FalsePositive] - counts[triage.AcceptedRisk] - counts[triage.ToFix]
+	log.Info().Msgf("%d false positives, %d accepted risks, %d to fix, %d without decision, saved to %s",
+		counts[triage.FalsePositive], counts[triage.AcceptedRisk], counts[triage.ToFix], undecided, statePath)
+}
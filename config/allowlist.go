@@ -1,16 +1,53 @@
 package config
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
 
+// AllowlistMatchCondition is how the criteria of an allowlist are
+// combined.
+type AllowlistMatchCondition int
+
+const (
+	// AllowlistMatchOr ignores a finding if any of the criteria match.
+	AllowlistMatchOr AllowlistMatchCondition = iota
+
+	// AllowlistMatchAnd ignores a finding only if every criterion that is
+	// set matches, ex: a regex only in some paths.
+	AllowlistMatchAnd
+)
+
+func (c AllowlistMatchCondition) String() string {
+	if c == AllowlistMatchAnd {
+		return "AND"
+	}
+	return "OR"
+}
+
+// parseMatchCondition parses the condition of an allowlist, OR if empty.
+func parseMatchCondition(s string) (AllowlistMatchCondition, error) {
+	switch strings.ToUpper(s) {
+	case "", "OR":
+		return AllowlistMatchOr, nil
+	case "AND":
+		return AllowlistMatchAnd, nil
+	}
+	return AllowlistMatchOr, fmt.Errorf("unknown allowlist condition %q, expected AND or OR", s)
+}
+
 // Allowlist allows a rule to be ignored for specific
 // regexes, paths, and/or commits
 type Allowlist struct {
-	// Short human readable description of the allowlist.
+	// Short human readable description of the allowlist. It is reported
+	// as the reason of the findings the allowlist suppresses.
 	Description string
 
+	// MatchCondition is how Commits, Paths, Regexes and StopWords are
+	// combined.
+	MatchCondition AllowlistMatchCondition
+
 	// Regexes is slice of content regular expressions that are allowed to be ignored.
 	Regexes []*regexp.Regexp
 
@@ -29,6 +66,12 @@ type Allowlist struct {
 	StopWords []string
 }
 
+// empty returns true if no criterion of the allowlist is set.
+func (a *Allowlist) empty() bool {
+	return a.Description == "" && len(a.Regexes) == 0 && len(a.Paths) == 0 &&
+		len(a.Commits) == 0 && len(a.StopWords) == 0
+}
+
 // CommitAllowed returns true if the commit is allowed to be ignored.
 func (a *Allowlist) CommitAllowed(c string) bool {
 	if c == "" {
@@ -61,3 +104,48 @@ func (a *Allowlist) ContainsStopWord(s string) bool {
 	}
 	return false
 }
+
+// FileAllowed returns true if the allowlist ignores every finding in the
+// file at path in the given commit, which is known before the file is
+// scanned. Allowlists with the AND condition and regexes or stopwords
+// need a finding, see FindingAllowed.
+func (a *Allowlist) FileAllowed(commit string, path string) bool {
+	if a.MatchCondition != AllowlistMatchAnd {
+		return a.CommitAllowed(commit) || a.pathAllowed(path)
+	}
+	if len(a.Regexes) != 0 || len(a.StopWords) != 0 || (len(a.Commits) == 0 && len(a.Paths) == 0) {
+		return false
+	}
+	return (len(a.Commits) == 0 || a.CommitAllowed(commit)) &&
+		(len(a.Paths) == 0 || a.pathAllowed(path))
+}
+
+// FindingAllowed returns true if the allowlist ignores a finding with the
+// given commit, path, secret, match and line. Regexes are matched against
+// the part of the finding selected by RegexTarget.
+func (a *Allowlist) FindingAllowed(commit, path, secret, match, line string) bool {
+	target := secret
+	switch a.RegexTarget {
+	case "match":
+		target = match
+	case "line":
+		target = line
+	}
+	if a.MatchCondition != AllowlistMatchAnd {
+		return a.CommitAllowed(commit) || a.pathAllowed(path) ||
+			a.RegexAllowed(target) || a.ContainsStopWord(secret)
+	}
+	if len(a.Commits) == 0 && len(a.Paths) == 0 && len(a.Regexes) == 0 && len(a.StopWords) == 0 {
+		return false
+	}
+	return (len(a.Commits) == 0 || a.CommitAllowed(commit)) &&
+		(len(a.Paths) == 0 || a.pathAllowed(path)) &&
+		(len(a.Regexes) == 0 || a.RegexAllowed(target)) &&
+		(len(a.StopWords) == 0 || a.ContainsStopWord(secret))
+}
+
+// pathAllowed is like PathAllowed but never allows an empty path, which is
+// used for content without a file like --pipe input.
+func (a *Allowlist) pathAllowed(path string) bool {
+	return path != "" && a.PathAllowed(path)
+}
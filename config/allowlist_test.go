@@ -91,3 +91,44 @@ func TestPathAllowed(t *testing.T) {
 		assert.Equal(t, tt.pathAllowed, tt.allowlist.PathAllowed(tt.path))
 	}
 }
+
+func TestAllowlistConditions(t *testing.T) {
+	or := Allowlist{
+		Regexes: []*regexp.Regexp{regexp.MustCompile("EXAMPLE")},
+		Paths:   []*regexp.Regexp{regexp.MustCompile(`^fixtures/`)},
+	}
+	and := or
+	and.MatchCondition = AllowlistMatchAnd
+	pathsAndCommits := Allowlist{
+		MatchCondition: AllowlistMatchAnd,
+		Paths:          []*regexp.Regexp{regexp.MustCompile(`^docs/`)},
+		Commits:        []string{"commitA"},
+	}
+
+	tests := []struct {
+		name           string
+		allowlist      Allowlist
+		commit         string
+		path           string
+		secret         string
+		fileAllowed    bool
+		findingAllowed bool
+	}{
+		{"or path", or, "", "fixtures/a.go", "secret", true, true},
+		{"or regex", or, "", "src/a.go", "EXAMPLE", false, true},
+		{"or none", or, "", "src/a.go", "secret", false, false},
+		{"and both", and, "", "fixtures/a.go", "EXAMPLE", false, true},
+		{"and path only", and, "", "fixtures/a.go", "secret", false, false},
+		{"and regex only", and, "", "src/a.go", "EXAMPLE", false, false},
+		{"and paths and commits", pathsAndCommits, "commitA", "docs/a.md", "secret", true, true},
+		{"and path without commit", pathsAndCommits, "commitB", "docs/a.md", "secret", false, false},
+		{"and commit without path", pathsAndCommits, "commitA", "src/a.go", "secret", false, false},
+		{"and empty", Allowlist{MatchCondition: AllowlistMatchAnd}, "commitA", "src/a.go", "secret", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.fileAllowed, tt.allowlist.FileAllowed(tt.commit, tt.path))
+			assert.Equal(t, tt.findingAllowed, tt.allowlist.FindingAllowed(tt.commit, tt.path, tt.secret, tt.secret, tt.secret))
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -13,43 +18,56 @@ import (
 //go:embed gitleaks.toml
 var DefaultConfig string
 
-// use to keep track of how many configs we can extend
-// yea I know, globals bad
-var extendDepth int
-
-const maxExtendDepth = 2
+// DefaultSource is the Source of the rules of the default config.
+const DefaultSource = "default config"
 
 // ViperConfig is the config struct used by the Viper config package
 // to parse the config file. This struct does not include regular expressions.
 // It is used as an intermediary to convert the Viper config to the Config struct.
+// Scalar fields of rules are pointers and slices are nil if they are not
+// set, so a rule override can set them to their zero value.
 type ViperConfig struct {
 	Description string
 	Extend      Extend
 	Rules       []struct {
 		ID          string
 		Description string
-		Entropy     float64
-		SecretGroup int
+		Entropy     *float64
+		SecretGroup *int
 		Regex       string
 		Keywords    []string
 		Path        string
 		Tags        []string
+		Severity    *string
+		Validator   *string
+		KeepInvalid *bool
+		Verify      *Verify
+		Tests       []RuleTest
 
-		Allowlist struct {
-			RegexTarget string
-			Regexes     []string
-			Paths       []string
-			Commits     []string
-			StopWords   []string
-		}
-	}
-	Allowlist struct {
-		RegexTarget string
-		Regexes     []string
-		Paths       []string
-		Commits     []string
-		StopWords   []string
+		Allowlist  ViperAllowlist
+		Allowlists []ViperAllowlist
 	}
+	Allowlist  ViperAllowlist
+	Allowlists []ViperAllowlist
+	Profiles   map[string]Profile
+}
+
+// ViperAllowlist is an allowlist as it is written in the config file. The
+// [allowlist] table is kept for existing configs, [[allowlists]] holds any
+// number of allowlists.
+type ViperAllowlist struct {
+	Description string
+	Condition   string
+	RegexTarget string
+	Regexes     []string
+	Paths       []string
+	Commits     []string
+	StopWords   []string `json:"stopwords"`
+}
+
+func (a ViperAllowlist) empty() bool {
+	return a.Description == "" && a.Condition == "" && a.RegexTarget == "" &&
+		len(a.Regexes) == 0 && len(a.Paths) == 0 && len(a.Commits) == 0 && len(a.StopWords) == 0
 }
 
 // Config is a configuration struct that contains rules and an allowlist if present.
@@ -58,9 +76,19 @@ type Config struct {
 	Path        string
 	Description string
 	Rules       map[string]Rule
-	Allowlist   Allowlist
+	Allowlists  []Allowlist
 	Keywords    []string
 
+	// Allowlist is the first of Allowlists.
+	//
+	// Deprecated: a config can have several allowlists, use Allowlists.
+	// An Allowlist set by code is used as the first of Allowlists, see
+	// UpgradeAllowlists.
+	Allowlist Allowlist
+
+	// Profiles are named scan settings, keyed by lowercase name.
+	Profiles map[string]Profile
+
 	// used to keep sarif results consistent
 	orderedRules []string
 }
@@ -71,9 +99,37 @@ type Extend struct {
 	Path       string
 	URL        string
 	UseDefault bool
+
+	// DisabledRules are IDs of rules that are not inherited from the
+	// extended config.
+	DisabledRules []string
+
+	// SHA256 pins the content fetched from URL to a hex encoded SHA-256
+	// hash. Content with another hash is rejected.
+	SHA256 string
 }
 
+// Translate compiles the rules and allowlists of the config and extends it
+// with the config referenced by extend, if any.
 func (vc *ViperConfig) Translate() (Config, error) {
+	return vc.translate("", nil)
+}
+
+// TranslateFrom is like Translate and records source, ex: the path of the
+// config file, as the Source of the rules the config defines.
+func (vc *ViperConfig) TranslateFrom(source string) (Config, error) {
+	// the config itself is the start of any extend cycle, extended
+	// configs are recorded the same way by extendWith
+	root := source
+	if source != DefaultSource {
+		if abs, err := filepath.Abs(source); err == nil {
+			root = abs
+		}
+	}
+	return vc.translate(source, []string{root})
+}
+
+func (vc *ViperConfig) translate(source string, visited []string) (Config, error) {
 	var (
 		keywords     []string
 		orderedRules []string
@@ -81,13 +137,23 @@ func (vc *ViperConfig) Translate() (Config, error) {
 	rulesMap := make(map[string]Rule)
 
 	for _, r := range vc.Rules {
-		var allowlistRegexes []*regexp.Regexp
-		for _, a := range r.Allowlist.Regexes {
-			allowlistRegexes = append(allowlistRegexes, regexp.MustCompile(a))
+		allowlists, err := translateAllowlists(r.Allowlist, r.Allowlists)
+		if err != nil {
+			return Config{}, fmt.Errorf("rule %s: %w", r.ID, err)
 		}
-		var allowlistPaths []*regexp.Regexp
-		for _, a := range r.Allowlist.Paths {
-			allowlistPaths = append(allowlistPaths, regexp.MustCompile(a))
+
+		// the keys the rule sets, which an override applies
+		set := map[string]bool{
+			"description": r.Description != "",
+			"entropy":     r.Entropy != nil,
+			"secretGroup": r.SecretGroup != nil,
+			"keywords":    r.Keywords != nil,
+			"tags":        r.Tags != nil,
+			"severity":    r.Severity != nil,
+			"validator":   r.Validator != nil,
+			"keepInvalid": r.KeepInvalid != nil,
+			"verify":      r.Verify != nil,
+			"tests":       r.Tests != nil,
 		}
 
 		if r.Keywords == nil {
@@ -104,77 +170,124 @@ func (vc *ViperConfig) Translate() (Config, error) {
 
 		var configRegex *regexp.Regexp
 		var configPathRegex *regexp.Regexp
-		if r.Regex == "" {
-			configRegex = nil
-		} else {
-			configRegex = regexp.MustCompile(r.Regex)
+		if r.Regex != "" {
+			if configRegex, err = regexp.Compile(r.Regex); err != nil {
+				return Config{}, fmt.Errorf("rule %s: invalid regex: %w", r.ID, err)
+			}
 		}
-		if r.Path == "" {
-			configPathRegex = nil
-		} else {
-			configPathRegex = regexp.MustCompile(r.Path)
+		if r.Path != "" {
+			if configPathRegex, err = regexp.Compile(r.Path); err != nil {
+				return Config{}, fmt.Errorf("rule %s: invalid path: %w", r.ID, err)
+			}
 		}
-		r := Rule{
+		rule := Rule{
 			Description: r.Description,
 			RuleID:      r.ID,
 			Regex:       configRegex,
 			Path:        configPathRegex,
-			SecretGroup: r.SecretGroup,
-			Entropy:     r.Entropy,
 			Tags:        r.Tags,
 			Keywords:    r.Keywords,
-			Allowlist: Allowlist{
-				RegexTarget: r.Allowlist.RegexTarget,
-				Regexes:     allowlistRegexes,
-				Paths:       allowlistPaths,
-				Commits:     r.Allowlist.Commits,
-				StopWords:   r.Allowlist.StopWords,
-			},
+			Verify:      r.Verify,
+			Tests:       r.Tests,
+			Source:      source,
+			Allowlists:  allowlists,
+		}
+		if r.SecretGroup != nil {
+			rule.SecretGroup = *r.SecretGroup
+		}
+		if r.Entropy != nil {
+			rule.Entropy = *r.Entropy
+		}
+		if r.Severity != nil {
+			rule.Severity = *r.Severity
 		}
-		orderedRules = append(orderedRules, r.RuleID)
+		if r.Validator != nil {
+			rule.Validator = *r.Validator
+		}
+		if r.KeepInvalid != nil {
+			rule.KeepInvalid = *r.KeepInvalid
+		}
+		if rule.Validator != "" && !KnownValidator(rule.Validator) {
+			return Config{}, fmt.Errorf("rule %s: unknown validator %q", r.ID, rule.Validator)
+		}
+		if rule.isOverride() {
+			rule.set = set
+		}
+		orderedRules = append(orderedRules, rule.RuleID)
 
-		if r.Regex != nil && r.SecretGroup > r.Regex.NumSubexp() {
-			return Config{}, fmt.Errorf("%s invalid regex secret group %d, max regex secret group %d", r.Description, r.SecretGroup, r.Regex.NumSubexp())
+		if rule.Regex != nil && rule.SecretGroup > rule.Regex.NumSubexp() {
+			return Config{}, fmt.Errorf("%s invalid regex secret group %d, max regex secret group %d", rule.Description, rule.SecretGroup, rule.Regex.NumSubexp())
 		}
-		rulesMap[r.RuleID] = r
+		rulesMap[rule.RuleID] = rule
 	}
-	var allowlistRegexes []*regexp.Regexp
-	for _, a := range vc.Allowlist.Regexes {
-		allowlistRegexes = append(allowlistRegexes, regexp.MustCompile(a))
-	}
-	var allowlistPaths []*regexp.Regexp
-	for _, a := range vc.Allowlist.Paths {
-		allowlistPaths = append(allowlistPaths, regexp.MustCompile(a))
+	allowlists, err := translateAllowlists(vc.Allowlist, vc.Allowlists)
+	if err != nil {
+		return Config{}, err
 	}
 	c := Config{
-		Description: vc.Description,
-		Extend:      vc.Extend,
-		Rules:       rulesMap,
-		Allowlist: Allowlist{
-			RegexTarget: vc.Allowlist.RegexTarget,
-			Regexes:     allowlistRegexes,
-			Paths:       allowlistPaths,
-			Commits:     vc.Allowlist.Commits,
-			StopWords:   vc.Allowlist.StopWords,
-		},
+		Description:  vc.Description,
+		Extend:       vc.Extend,
+		Rules:        rulesMap,
+		Allowlists:   allowlists,
 		Keywords:     keywords,
+		Profiles:     make(map[string]Profile),
 		orderedRules: orderedRules,
 	}
+	for name, p := range vc.Profiles {
+		c.Profiles[strings.ToLower(name)] = p
+	}
 
-	if maxExtendDepth != extendDepth {
-		// disallow both usedefault and path from being set
-		if c.Extend.Path != "" && c.Extend.UseDefault {
-			log.Fatal().Msg("unable to load config due to extend.path and extend.useDefault being set")
+	if err := c.extendWith(visited); err != nil {
+		return Config{}, err
+	}
+	c.UpgradeAllowlists()
+	return c, nil
+}
+
+// translateAllowlists compiles the [allowlist] table, if set, followed by
+// the [[allowlists]] array.
+func translateAllowlists(legacy ViperAllowlist, allowlists []ViperAllowlist) ([]Allowlist, error) {
+	if !legacy.empty() {
+		allowlists = append([]ViperAllowlist{legacy}, allowlists...)
+	}
+	var res []Allowlist
+	for _, a := range allowlists {
+		condition, err := parseMatchCondition(a.Condition)
+		if err != nil {
+			return nil, err
 		}
-		if c.Extend.UseDefault {
-			c.extendDefault()
-		} else if c.Extend.Path != "" {
-			c.extendPath()
+		regexes, err := compileRegexes(a.Regexes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist regex: %w", err)
 		}
-
+		paths, err := compileRegexes(a.Paths)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist path: %w", err)
+		}
+		res = append(res, Allowlist{
+			Description:    a.Description,
+			MatchCondition: condition,
+			RegexTarget:    a.RegexTarget,
+			Regexes:        regexes,
+			Paths:          paths,
+			Commits:        a.Commits,
+			StopWords:      a.StopWords,
+		})
 	}
+	return res, nil
+}
 
-	return c, nil
+// compileRegexes compiles each pattern, returning the first error.
+func compileRegexes(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
 }
 
 func (c *Config) OrderedRules() []Rule {
@@ -187,67 +300,250 @@ func (c *Config) OrderedRules() []Rule {
 	return orderedRules
 }
 
-func (c *Config) extendDefault() {
-	extendDepth++
-	viper.SetConfigType("toml")
-	if err := viper.ReadConfig(strings.NewReader(DefaultConfig)); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	defaultViperConfig := ViperConfig{}
-	if err := viper.Unmarshal(&defaultViperConfig); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+// FilterRules removes the rules for which keep returns false, ex: the
+// rules a RuleSelection does not select.
+func (c *Config) FilterRules(keep func(Rule) bool) {
+	kept := make(map[string]Rule)
+	for id, rule := range c.Rules {
+		if keep(rule) {
+			kept[id] = rule
+		}
 	}
-	cfg, err := defaultViperConfig.Translate()
-	if err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+	c.Rules = kept
+}
+
+// UpgradeAllowlists makes the deprecated Allowlist fields of the config
+// and its rules agree with Allowlists. An Allowlist set by code replaces
+// the first of Allowlists, or is the only allowlist if there are none.
+// Otherwise Allowlist is set to the first of Allowlists. Loaded configs
+// and the configs of a detector are upgraded.
+func (c *Config) UpgradeAllowlists() {
+	c.Allowlist, c.Allowlists = upgradeAllowlists(c.Allowlist, c.Allowlists)
+	rules := make(map[string]Rule, len(c.Rules))
+	for id, rule := range c.Rules {
+		rule.Allowlist, rule.Allowlists = upgradeAllowlists(rule.Allowlist, rule.Allowlists)
+		rules[id] = rule
 	}
-	log.Debug().Msg("extending config with default config")
-	c.extend(cfg)
+	c.Rules = rules
+}
 
+func upgradeAllowlists(legacy Allowlist, allowlists []Allowlist) (Allowlist, []Allowlist) {
+	switch {
+	case legacy.empty():
+	case len(allowlists) == 0:
+		allowlists = []Allowlist{legacy}
+	case !reflect.DeepEqual(legacy, allowlists[0]):
+		allowlists = append([]Allowlist{legacy}, allowlists[1:]...)
+	}
+	if len(allowlists) == 0 {
+		return Allowlist{}, nil
+	}
+	return allowlists[0], allowlists
 }
 
-func (c *Config) extendPath() {
-	extendDepth++
-	viper.SetConfigFile(c.Extend.Path)
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+// extendWith extends c with the config referenced by c.Extend. visited
+// holds the configs that are already being extended, in order, and is used
+// to detect cycles.
+func (c *Config) extendWith(visited []string) error {
+	var (
+		source string
+		load   func() (ViperConfig, error)
+	)
+	switch {
+	case c.Extend.Path != "" && c.Extend.UseDefault:
+		return fmt.Errorf("unable to load config due to extend.path and extend.useDefault being set")
+	case c.Extend.URL != "" && (c.Extend.Path != "" || c.Extend.UseDefault):
+		return fmt.Errorf("unable to load config due to extend.url being set with extend.path or extend.useDefault")
+	case c.Extend.UseDefault:
+		source = DefaultSource
+		load = func() (ViperConfig, error) {
+			return readViperConfig(strings.NewReader(DefaultConfig), "toml")
+		}
+	case c.Extend.Path != "":
+		source = c.Extend.Path
+		if abs, err := filepath.Abs(c.Extend.Path); err == nil {
+			source = abs
+		}
+		load = func() (ViperConfig, error) {
+			v := viper.New()
+			v.SetConfigFile(c.Extend.Path)
+			if err := v.ReadInConfig(); err != nil {
+				return ViperConfig{}, err
+			}
+			return unmarshalViperConfig(v)
+		}
+	case c.Extend.URL != "":
+		source = c.Extend.URL
+		load = func() (ViperConfig, error) {
+			data, err := fetchExtendURL(c.Extend.URL, c.Extend.SHA256)
+			if err != nil {
+				return ViperConfig{}, err
+			}
+			return readViperConfig(bytes.NewReader(data), urlConfigType(c.Extend.URL))
+		}
+	default:
+		return nil
+	}
+
+	for _, v := range visited {
+		if v == source {
+			return fmt.Errorf("extend cycle detected: %s -> %s", strings.Join(visited, " -> "), source)
+		}
 	}
-	extensionViperConfig := ViperConfig{}
-	if err := viper.Unmarshal(&extensionViperConfig); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+
+	vc, err := load()
+	if err != nil {
+		return fmt.Errorf("failed to load extended config %s, err: %w", source, err)
 	}
-	cfg, err := extensionViperConfig.Translate()
+	cfg, err := vc.translate(source, append(visited, source))
 	if err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+		return fmt.Errorf("failed to load extended config %s, err: %w", source, err)
 	}
-	log.Debug().Msgf("extending config with %s", c.Extend.Path)
-	c.extend(cfg)
+	log.Debug().Msgf("extending config with %s", source)
+	return c.extend(cfg)
 }
 
-func (c *Config) extendURL() {
-	// TODO
+// readViperConfig parses a config of the given type, ex: toml, yaml or
+// json.
+func readViperConfig(r io.Reader, configType string) (ViperConfig, error) {
+	v := viper.New()
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(r); err != nil {
+		return ViperConfig{}, err
+	}
+	return unmarshalViperConfig(v)
+}
+
+func unmarshalViperConfig(v *viper.Viper) (ViperConfig, error) {
+	var vc ViperConfig
+	if err := v.Unmarshal(&vc); err != nil {
+		return ViperConfig{}, err
+	}
+	return vc, nil
 }
 
-func (c *Config) extend(extensionConfig Config) {
-	for ruleID, rule := range extensionConfig.Rules {
-		if _, ok := c.Rules[ruleID]; !ok {
+// extend merges the rules and allowlist of extensionConfig into c. Rules of
+// c take precedence, except for rules without a regex and path, which are
+// overrides that are merged into the inherited rule of the same ID. Rules
+// listed in extend.disabledRules are not inherited.
+func (c *Config) extend(extensionConfig Config) error {
+	disabled := make(map[string]bool)
+	for _, id := range c.Extend.DisabledRules {
+		if _, ok := extensionConfig.Rules[id]; !ok {
+			log.Warn().Msgf("disabled rule %s is not defined by the extended config", id)
+		}
+		disabled[id] = true
+	}
+
+	for _, ruleID := range extensionConfig.orderedRules {
+		rule := extensionConfig.Rules[ruleID]
+		if disabled[ruleID] {
+			log.Debug().Msgf("disabling %s", ruleID)
+			if override, ok := c.Rules[ruleID]; ok && override.isOverride() {
+				delete(c.Rules, ruleID)
+			}
+			continue
+		}
+		base, ok := c.Rules[ruleID]
+		switch {
+		case !ok:
 			log.Trace().Msgf("adding %s to base config", ruleID)
 			c.Rules[ruleID] = rule
-			c.Keywords = append(c.Keywords, rule.Keywords...)
+			c.orderedRules = append(c.orderedRules, ruleID)
+		case base.isOverride():
+			log.Trace().Msgf("overriding %s", ruleID)
+			merged := rule.merge(base)
+			if merged.Regex != nil && merged.SecretGroup > merged.Regex.NumSubexp() {
+				return fmt.Errorf("%s invalid regex secret group %d, max regex secret group %d", merged.Description, merged.SecretGroup, merged.Regex.NumSubexp())
+			}
+			c.Rules[ruleID] = merged
+		}
+	}
+	for _, ruleID := range c.orderedRules {
+		if rule, ok := c.Rules[ruleID]; ok && rule.isOverride() && !disabled[ruleID] {
+			if _, ok := extensionConfig.Rules[ruleID]; !ok {
+				return fmt.Errorf("rule %s has no regex or path and overrides no rule of the extended config", ruleID)
+			}
 		}
 	}
 
 	// append allowlists, not attempting to merge
-	c.Allowlist.Commits = append(c.Allowlist.Commits,
-		extensionConfig.Allowlist.Commits...)
-	c.Allowlist.Paths = append(c.Allowlist.Paths,
-		extensionConfig.Allowlist.Paths...)
-	c.Allowlist.Regexes = append(c.Allowlist.Regexes,
-		extensionConfig.Allowlist.Regexes...)
+	c.Allowlists = append(c.Allowlists, extensionConfig.Allowlists...)
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	for name, p := range extensionConfig.Profiles {
+		if _, ok := c.Profiles[name]; !ok {
+			c.Profiles[name] = p
+		}
+	}
+
+	c.Keywords = nil
+	for _, rule := range c.OrderedRules() {
+		for _, k := range rule.Keywords {
+			c.Keywords = append(c.Keywords, strings.ToLower(k))
+		}
+	}
+	return nil
+}
+
+// FileNames are the names of the config files gitleaks looks for in a
+// directory, in order of precedence.
+var FileNames = []string{".gitleaks.toml", ".gitleaks.yaml", ".gitleaks.yml", ".gitleaks.json"}
+
+// FindFile returns the path of the config file in dir, or an empty string
+// if there is none. If dir has several config files the first of FileNames
+// is used.
+func FindFile(dir string) string {
+	for _, name := range FileNames {
+		p := filepath.Join(dir, name)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// LoadFile reads and translates the config file at path. The format is
+// taken from the file extension, ex: .toml, .yaml or .json.
+func LoadFile(path string) (Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return Config{}, err
+	}
+	vc, err := unmarshalViperConfig(v)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := vc.TranslateFrom(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Inherit merges parent into c the same way an extended config is merged:
+// rules of c take precedence, rules without a regex and path override the
+// parent's rule and extend.disabledRules of c disables parent rules.
+func (c Config) Inherit(parent Config) (Config, error) {
+	rules := make(map[string]Rule, len(c.Rules))
+	for id, r := range c.Rules {
+		rules[id] = r
+	}
+	c.Rules = rules
+	profiles := make(map[string]Profile, len(c.Profiles))
+	for name, p := range c.Profiles {
+		profiles[name] = p
+	}
+	c.Profiles = profiles
+	c.orderedRules = append([]string{}, c.orderedRules...)
+	c.Allowlists = c.Allowlists[:len(c.Allowlists):len(c.Allowlists)]
+	if err := c.extend(parent); err != nil {
+		return Config{}, err
+	}
+	c.UpgradeAllowlists()
+	return c, nil
 }
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxExtendURLBytes limits the size of a config fetched by extend.url.
+const maxExtendURLBytes = 10 << 20
+
+// extendClient is the http client used to fetch extend.url configs.
+var extendClient = &http.Client{Timeout: 30 * time.Second}
+
+// CacheDir returns the directory configs fetched by extend.url are cached
+// in. It is GITLEAKS_CACHE_DIR if set, otherwise a gitleaks directory in
+// the user's cache directory.
+func CacheDir() (string, error) {
+	if dir := os.Getenv("GITLEAKS_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitleaks"), nil
+}
+
+// fetchExtendURL downloads the config at url and caches it on disk. If the
+// download fails the cached copy is used instead, so scans keep working
+// offline. If pin is set, content with another SHA-256 hash is rejected.
+func fetchExtendURL(url string, pin string) ([]byte, error) {
+	pin = strings.ToLower(strings.TrimPrefix(pin, "sha256:"))
+	cachePath := ""
+	if dir, err := CacheDir(); err != nil {
+		log.Warn().Err(err).Msg("unable to find cache directory, extended config will not be cached")
+	} else {
+		sum := sha256.Sum256([]byte(url))
+		cachePath = filepath.Join(dir, hex.EncodeToString(sum[:])+".toml")
+	}
+
+	data, fetchErr := download(url)
+	if fetchErr == nil {
+		if err := checkPin(data, pin); err != nil {
+			return nil, err
+		}
+		if cachePath != "" {
+			if err := writeCache(cachePath, data); err != nil {
+				log.Warn().Err(err).Msgf("unable to cache extended config %s", url)
+			}
+		}
+		return data, nil
+	}
+
+	if cachePath == "" {
+		return nil, fetchErr
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w, no cached copy available", fetchErr)
+		}
+		return nil, fmt.Errorf("%w, reading cached copy: %s", fetchErr, err)
+	}
+	if err := checkPin(data, pin); err != nil {
+		return nil, fmt.Errorf("cached copy at %s: %w", cachePath, err)
+	}
+	log.Warn().Msgf("unable to fetch extended config %s, using cached copy %s: %s", url, cachePath, fetchErr)
+	return data, nil
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := extendClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxExtendURLBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxExtendURLBytes {
+		return nil, fmt.Errorf("config is larger than %d bytes", maxExtendURLBytes)
+	}
+	return data, nil
+}
+
+// checkPin returns an error if pin is set and does not match the SHA-256
+// hash of data.
+func checkPin(data []byte, pin string) error {
+	if pin == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != pin {
+		return fmt.Errorf("sha256 mismatch, expected %s, got %s", pin, got)
+	}
+	return nil
+}
+
+// writeCache atomically replaces the cached copy at path.
+func writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".extend-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// urlConfigType returns the config type of a config fetched from url,
+// based on the extension of its path. Configs without a known extension
+// are read as toml.
+func urlConfigType(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		switch ext := strings.ToLower(path.Ext(u.Path)); ext {
+		case ".yaml", ".yml", ".json":
+			return ext[1:]
+		}
+	}
+	return "toml"
+}
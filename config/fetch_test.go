@@ -0,0 +1,103 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendURL(t *testing.T) {
+	extension, err := os.ReadFile(configPath + "extend_3.toml")
+	require.NoError(t, err)
+	sum := sha256.Sum256(extension)
+	pin := hex.EncodeToString(sum[:])
+
+	online := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(extension)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		extend    Extend
+		online    bool
+		cached    bool
+		wantRules []string
+		wantError string
+	}{
+		{
+			name:      "fetch",
+			extend:    Extend{URL: srv.URL + "/rules.toml"},
+			online:    true,
+			wantRules: []string{"aws-secret-key-again-again"},
+		},
+		{
+			name:      "fetch with pin",
+			extend:    Extend{URL: srv.URL + "/rules.toml", SHA256: "sha256:" + pin},
+			online:    true,
+			wantRules: []string{"aws-secret-key-again-again"},
+		},
+		{
+			name:      "pin mismatch",
+			extend:    Extend{URL: srv.URL + "/rules.toml", SHA256: "0000"},
+			online:    true,
+			wantError: "sha256 mismatch, expected 0000, got " + pin,
+		},
+		{
+			name:      "offline uses cache",
+			extend:    Extend{URL: srv.URL + "/rules.toml", SHA256: pin},
+			online:    false,
+			cached:    true,
+			wantRules: []string{"aws-secret-key-again-again"},
+		},
+		{
+			name:      "offline without cache",
+			extend:    Extend{URL: srv.URL + "/other.toml"},
+			online:    false,
+			wantError: "unexpected status 503 Service Unavailable, no cached copy available",
+		},
+		{
+			name:      "url and path",
+			extend:    Extend{URL: srv.URL + "/rules.toml", Path: configPath + "extend_3.toml"},
+			online:    true,
+			wantError: "extend.url being set with extend.path or extend.useDefault",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every test starts with an empty cache
+			t.Setenv("GITLEAKS_CACHE_DIR", t.TempDir())
+			if tt.cached {
+				online = true
+				_, err := fetchExtendURL(tt.extend.URL, tt.extend.SHA256)
+				require.NoError(t, err)
+			}
+			online = tt.online
+			vc := ViperConfig{Extend: tt.extend}
+			cfg, err := vc.Translate()
+			if tt.wantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			var ruleIDs []string
+			for id := range cfg.Rules {
+				ruleIDs = append(ruleIDs, id)
+			}
+			assert.Equal(t, tt.wantRules, ruleIDs)
+		})
+	}
+}
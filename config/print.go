@@ -0,0 +1,396 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// printedConfig is a resolved config in the shape of a config file, with
+// the keys used by the default config.
+type printedConfig struct {
+	Description string                    `json:"description,omitempty"`
+	Allowlists  []printedAllowlist        `json:"allowlists,omitempty"`
+	Profiles    map[string]printedProfile `json:"profiles,omitempty"`
+	Rules       []printedRule             `json:"rules"`
+
+	// RuleSources is an annotation mapping rule IDs to their Source. It
+	// is a key of the root table, which the schema leaves open, and is
+	// ignored when the config is loaded.
+	RuleSources map[string]string `json:"ruleSources,omitempty"`
+}
+
+type printedAllowlist struct {
+	Description string   `json:"description,omitempty"`
+	Condition   string   `json:"condition,omitempty"`
+	RegexTarget string   `json:"regexTarget,omitempty"`
+	Regexes     []string `json:"regexes,omitempty"`
+	Paths       []string `json:"paths,omitempty"`
+	Commits     []string `json:"commits,omitempty"`
+	StopWords   []string `json:"stopwords,omitempty"`
+}
+
+type printedProfile struct {
+	Description  string   `json:"description,omitempty"`
+	EnableRules  []string `json:"enableRules,omitempty"`
+	DisableRules []string `json:"disableRules,omitempty"`
+	EnableTags   []string `json:"enableTags,omitempty"`
+	DisableTags  []string `json:"disableTags,omitempty"`
+	Redact       *uint    `json:"redact,omitempty"`
+	ExitCode     *int     `json:"exitCode,omitempty"`
+}
+
+type printedRule struct {
+	// Source is printed as a comment in toml and in RuleSources in json
+	Source      string             `json:"-"`
+	ID          string             `json:"id"`
+	Description string             `json:"description,omitempty"`
+	Regex       string             `json:"regex,omitempty"`
+	Path        string             `json:"path,omitempty"`
+	SecretGroup int                `json:"secretGroup,omitempty"`
+	Entropy     float64            `json:"entropy,omitempty"`
+	Keywords    []string           `json:"keywords,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Severity    string             `json:"severity,omitempty"`
+	Validator   string             `json:"validator,omitempty"`
+	KeepInvalid bool               `json:"keepInvalid,omitempty"`
+	Verify      *printedVerify     `json:"verify,omitempty"`
+	Allowlists  []printedAllowlist `json:"allowlists,omitempty"`
+	Tests       []printedRuleTest  `json:"tests,omitempty"`
+}
+
+type printedVerify struct {
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	ExpectedStatus []int             `json:"expectedStatus,omitempty"`
+}
+
+type printedRuleTest struct {
+	Description    string   `json:"description,omitempty"`
+	TruePositives  []string `json:"truePositives,omitempty"`
+	FalsePositives []string `json:"falsePositives,omitempty"`
+	Secret         string   `json:"secret,omitempty"`
+	Path           string   `json:"path,omitempty"`
+}
+
+// WriteJSON writes the resolved config as a json config. Extended configs
+// are merged in, so there is no extend table. The Source of every rule is
+// listed in a "ruleSources" object, which is ignored when the config is
+// loaded.
+func (c *Config) WriteJSON(w io.Writer) error {
+	p := c.printed()
+	for _, r := range p.Rules {
+		if r.Source == "" {
+			continue
+		}
+		if p.RuleSources == nil {
+			p.RuleSources = make(map[string]string)
+		}
+		p.RuleSources[r.ID] = r.Source
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(p)
+}
+
+// WriteTOML writes the resolved config as a toml config. Extended configs
+// are merged in, so there is no [extend] table. Every rule is preceded by
+// a comment with its Source.
+func (c *Config) WriteTOML(w io.Writer) error {
+	p := c.printed()
+	var sb strings.Builder
+
+	if p.Description != "" {
+		writeTOMLValue(&sb, "description", tomlString(p.Description))
+		sb.WriteString("\n")
+	}
+	for _, a := range p.Allowlists {
+		sb.WriteString("[[allowlists]]\n")
+		writeTOMLAllowlist(&sb, a)
+		sb.WriteString("\n")
+	}
+
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := p.Profiles[name]
+		fmt.Fprintf(&sb, "[profiles.%s]\n", tomlKey(name))
+		writeTOMLString(&sb, "description", profile.Description)
+		writeTOMLArray(&sb, "enableRules", profile.EnableRules, false)
+		writeTOMLArray(&sb, "disableRules", profile.DisableRules, false)
+		writeTOMLArray(&sb, "enableTags", profile.EnableTags, false)
+		writeTOMLArray(&sb, "disableTags", profile.DisableTags, false)
+		if profile.Redact != nil {
+			writeTOMLValue(&sb, "redact", strconv.FormatUint(uint64(*profile.Redact), 10))
+		}
+		if profile.ExitCode != nil {
+			writeTOMLValue(&sb, "exitCode", strconv.Itoa(*profile.ExitCode))
+		}
+		sb.WriteString("\n")
+	}
+
+	for i, r := range p.Rules {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("[[rules]]\n")
+		if r.Source != "" {
+			fmt.Fprintf(&sb, "# source: %s\n", r.Source)
+		}
+		writeTOMLValue(&sb, "id", tomlString(r.ID))
+		writeTOMLString(&sb, "description", r.Description)
+		if r.Regex != "" {
+			writeTOMLValue(&sb, "regex", tomlLiteral(r.Regex))
+		}
+		if r.Path != "" {
+			writeTOMLValue(&sb, "path", tomlLiteral(r.Path))
+		}
+		if r.SecretGroup != 0 {
+			writeTOMLValue(&sb, "secretGroup", strconv.Itoa(r.SecretGroup))
+		}
+		if r.Entropy != 0 {
+			writeTOMLValue(&sb, "entropy", tomlFloat(r.Entropy))
+		}
+		writeTOMLArray(&sb, "keywords", r.Keywords, false)
+		writeTOMLArray(&sb, "tags", r.Tags, false)
+		writeTOMLString(&sb, "severity", r.Severity)
+		writeTOMLString(&sb, "validator", r.Validator)
+		if r.KeepInvalid {
+			writeTOMLValue(&sb, "keepInvalid", "true")
+		}
+		if r.Verify != nil {
+			sb.WriteString("[rules.verify]\n")
+			writeTOMLString(&sb, "method", r.Verify.Method)
+			writeTOMLString(&sb, "url", r.Verify.URL)
+			if len(r.Verify.Headers) != 0 {
+				keys := make([]string, 0, len(r.Verify.Headers))
+				for k := range r.Verify.Headers {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				headers := make([]string, len(keys))
+				for i, k := range keys {
+					headers[i] = tomlKey(k) + " = " + tomlString(r.Verify.Headers[k])
+				}
+				writeTOMLValue(&sb, "headers", "{ "+strings.Join(headers, ", ")+" }")
+			}
+			writeTOMLString(&sb, "body", r.Verify.Body)
+			if len(r.Verify.ExpectedStatus) != 0 {
+				codes := make([]string, len(r.Verify.ExpectedStatus))
+				for i, code := range r.Verify.ExpectedStatus {
+					codes[i] = strconv.Itoa(code)
+				}
+				writeTOMLValue(&sb, "expectedStatus", "["+strings.Join(codes, ", ")+"]")
+			}
+		}
+		for _, a := range r.Allowlists {
+			sb.WriteString("[[rules.allowlists]]\n")
+			writeTOMLAllowlist(&sb, a)
+		}
+		for _, test := range r.Tests {
+			sb.WriteString("[[rules.tests]]\n")
+			writeTOMLString(&sb, "description", test.Description)
+			writeTOMLArray(&sb, "truePositives", test.TruePositives, true)
+			writeTOMLArray(&sb, "falsePositives", test.FalsePositives, true)
+			writeTOMLString(&sb, "secret", test.Secret)
+			writeTOMLString(&sb, "path", test.Path)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (c *Config) printed() printedConfig {
+	p := printedConfig{
+		Description: c.Description,
+		Allowlists:  printAllowlists(c.Allowlists),
+		Rules:       []printedRule{},
+	}
+	if len(c.Profiles) != 0 {
+		p.Profiles = make(map[string]printedProfile, len(c.Profiles))
+		for name, profile := range c.Profiles {
+			p.Profiles[name] = printedProfile{
+				Description:  profile.Description,
+				EnableRules:  profile.EnableRules,
+				DisableRules: profile.DisableRules,
+				EnableTags:   profile.EnableTags,
+				DisableTags:  profile.DisableTags,
+				Redact:       profile.Redact,
+				ExitCode:     profile.ExitCode,
+			}
+		}
+	}
+
+	for _, rule := range c.OrderedRules() {
+		r := printedRule{
+			Source:      rule.Source,
+			ID:          rule.RuleID,
+			Description: rule.Description,
+			SecretGroup: rule.SecretGroup,
+			Entropy:     rule.Entropy,
+			Keywords:    rule.Keywords,
+			Tags:        rule.Tags,
+			Severity:    rule.Severity,
+			Validator:   rule.Validator,
+			KeepInvalid: rule.KeepInvalid,
+			Allowlists:  printAllowlists(rule.Allowlists),
+		}
+		if rule.Regex != nil {
+			r.Regex = rule.Regex.String()
+		}
+		if rule.Path != nil {
+			r.Path = rule.Path.String()
+		}
+		if rule.Verify != nil {
+			r.Verify = &printedVerify{
+				Method:         rule.Verify.Method,
+				URL:            rule.Verify.URL,
+				Headers:        rule.Verify.Headers,
+				Body:           rule.Verify.Body,
+				ExpectedStatus: rule.Verify.ExpectedStatus,
+			}
+		}
+		for _, test := range rule.Tests {
+			r.Tests = append(r.Tests, printedRuleTest(test))
+		}
+		p.Rules = append(p.Rules, r)
+	}
+	return p
+}
+
+func printAllowlists(allowlists []Allowlist) []printedAllowlist {
+	var res []printedAllowlist
+	for _, a := range allowlists {
+		p := printedAllowlist{
+			Description: a.Description,
+			RegexTarget: a.RegexTarget,
+			Regexes:     regexStrings(a.Regexes),
+			Paths:       regexStrings(a.Paths),
+			Commits:     a.Commits,
+			StopWords:   a.StopWords,
+		}
+		if a.MatchCondition != AllowlistMatchOr {
+			p.Condition = a.MatchCondition.String()
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func regexStrings(regexes []*regexp.Regexp) []string {
+	var res []string
+	for _, re := range regexes {
+		res = append(res, re.String())
+	}
+	return res
+}
+
+func writeTOMLAllowlist(sb *strings.Builder, a printedAllowlist) {
+	writeTOMLString(sb, "description", a.Description)
+	writeTOMLString(sb, "condition", a.Condition)
+	writeTOMLString(sb, "regexTarget", a.RegexTarget)
+	writeTOMLArray(sb, "regexes", a.Regexes, true)
+	writeTOMLArray(sb, "paths", a.Paths, true)
+	writeTOMLArray(sb, "commits", a.Commits, false)
+	writeTOMLArray(sb, "stopwords", a.StopWords, true)
+}
+
+func writeTOMLValue(sb *strings.Builder, key string, value string) {
+	fmt.Fprintf(sb, "%s = %s\n", key, value)
+}
+
+// writeTOMLString writes key if s is not empty.
+func writeTOMLString(sb *strings.Builder, key string, s string) {
+	if s != "" {
+		writeTOMLValue(sb, key, tomlString(s))
+	}
+}
+
+// writeTOMLArray writes key if values is not empty. Patterns are written
+// as literal strings, one per line, like the default config does.
+func writeTOMLArray(sb *strings.Builder, key string, values []string, patterns bool) {
+	if len(values) == 0 {
+		return
+	}
+	if !patterns {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = tomlString(v)
+		}
+		writeTOMLValue(sb, key, "["+strings.Join(quoted, ", ")+"]")
+		return
+	}
+	fmt.Fprintf(sb, "%s = [\n", key)
+	for _, v := range values {
+		fmt.Fprintf(sb, "    %s,\n", tomlLiteral(v))
+	}
+	sb.WriteString("]\n")
+}
+
+// tomlString returns s as a toml basic string.
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// tomlLiteral returns s as a toml multi-line literal string, which keeps
+// regexes readable, or as a basic string if s can not be written as one.
+func tomlLiteral(s string) string {
+	if strings.Contains(s, "'''") || strings.HasSuffix(s, "'") ||
+		strings.IndexFunc(s, func(r rune) bool { return (r < 0x20 && r != '\t') || r == 0x7f }) != -1 {
+		return tomlString(s)
+	}
+	return "'''" + s + "'''"
+}
+
+// tomlKey returns k as a bare key if possible, otherwise quoted.
+func tomlKey(k string) string {
+	if k == "" || strings.IndexFunc(k, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) != -1 {
+		return tomlString(k)
+	}
+	return k
+}
+
+// tomlFloat formats f so it is read back as a float.
+func tomlFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
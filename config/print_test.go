@@ -0,0 +1,82 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrintRoundTrip checks that a printed config loads back to the same
+// config.
+func TestPrintRoundTrip(t *testing.T) {
+	vc, err := readViperConfig(strings.NewReader(DefaultConfig), "toml")
+	require.NoError(t, err)
+	defaultConfig, err := vc.TranslateFrom(DefaultSource)
+	require.NoError(t, err)
+
+	configs := map[string]Config{"default": defaultConfig}
+	for _, name := range []string{"extend_override.toml", "profiles.toml", "rule_tests.toml", "verify.toml", "formats.yaml"} {
+		cfg, err := LoadFile(configPath + name)
+		require.NoError(t, err)
+		configs[name] = cfg
+	}
+
+	for name, cfg := range configs {
+		for _, format := range []string{"toml", "json"} {
+			t.Run(name+" as "+format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if format == "toml" {
+					require.NoError(t, cfg.WriteTOML(&buf))
+				} else {
+					require.NoError(t, cfg.WriteJSON(&buf))
+				}
+				path := filepath.Join(t.TempDir(), "printed."+format)
+				require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+				printed, err := LoadFile(path)
+				require.NoError(t, err)
+				for id, rule := range printed.Rules {
+					rule.Source = cfg.Rules[id].Source
+					printed.Rules[id] = rule
+				}
+				assert.Equal(t, cfg.Rules, printed.Rules)
+				assert.Equal(t, cfg.OrderedRules(), printed.OrderedRules())
+				assert.Equal(t, cfg.Allowlists, printed.Allowlists)
+				assert.Equal(t, cfg.Profiles, printed.Profiles)
+				assert.Equal(t, cfg.Keywords, printed.Keywords)
+			})
+		}
+	}
+}
+
+func TestPrintSource(t *testing.T) {
+	cfg, err := LoadFile(configPath + "extend_override.toml")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteTOML(&buf))
+	assert.Contains(t, buf.String(), "[[rules]]\n# source: "+absConfigPath(t, "extend_1.toml")+
+		", overridden by "+configPath+"extend_override.toml\nid = \"aws-access-key\"\n")
+	assert.Contains(t, buf.String(), "[[rules]]\n# source: "+absConfigPath(t, "extend_3.toml")+
+		"\nid = \"aws-secret-key-again-again\"\n")
+	assert.NotContains(t, buf.String(), "[extend]")
+
+	// printed json follows the schema, sources are listed outside of the
+	// rules
+	buf.Reset()
+	require.NoError(t, cfg.WriteJSON(&buf))
+	var printed map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+	assert.Equal(t, absConfigPath(t, "extend_3.toml"), printed["ruleSources"].(map[string]interface{})["aws-secret-key-again-again"])
+	data, err := JSONSchema()
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assertSchemaKeys(t, schema, printed, "")
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is a named set of scan settings defined in a config, ex:
+//
+//	[profiles.ci]
+//	enableTags = ["aws", "gcp"]
+//	disableRules = ["generic-api-key"]
+//	redact = 100
+//	exitCode = 2
+//
+// Profiles are selected with --profile. Flags given on the command line
+// take precedence over the settings of a profile.
+type Profile struct {
+	// Description is an optional description of the profile.
+	Description string
+
+	// RuleSelection selects the rules that are run.
+	RuleSelection `mapstructure:",squash"`
+
+	// Redact is the redaction level, like --redact, if set.
+	Redact *uint
+
+	// ExitCode is the exit code used when leaks are found, like
+	// --exit-code, if set.
+	ExitCode *int
+}
+
+// RuleSelection selects rules by ID and tag. If EnableRules or EnableTags
+// are set only the rules listed by ID or with one of the tags are selected.
+// Rules listed in DisableRules or with one of DisableTags are never
+// selected. Tags are matched ignoring case.
+type RuleSelection struct {
+	EnableRules  []string
+	DisableRules []string
+	EnableTags   []string
+	DisableTags  []string
+}
+
+// Empty returns true if the selection selects every rule.
+func (s RuleSelection) Empty() bool {
+	return len(s.EnableRules) == 0 && len(s.DisableRules) == 0 &&
+		len(s.EnableTags) == 0 && len(s.DisableTags) == 0
+}
+
+// Override returns the selection with the rules other enables or disables
+// replacing the ones s enables or disables, like flags replace the
+// settings of a profile. EnableRules and EnableTags are one set: if other
+// sets either, both are taken from other. The same goes for DisableRules
+// and DisableTags.
+func (s RuleSelection) Override(other RuleSelection) RuleSelection {
+	res := s
+	if len(other.EnableRules) != 0 || len(other.EnableTags) != 0 {
+		res.EnableRules, res.EnableTags = other.EnableRules, other.EnableTags
+	}
+	if len(other.DisableRules) != 0 || len(other.DisableTags) != 0 {
+		res.DisableRules, res.DisableTags = other.DisableRules, other.DisableTags
+	}
+	return res
+}
+
+// Selects returns true if the rule is selected.
+func (s RuleSelection) Selects(rule Rule) bool {
+	if containsFold(s.DisableRules, rule.RuleID, false) {
+		return false
+	}
+	for _, tag := range rule.Tags {
+		if containsFold(s.DisableTags, tag, true) {
+			return false
+		}
+	}
+	if len(s.EnableRules) == 0 && len(s.EnableTags) == 0 {
+		return true
+	}
+	if containsFold(s.EnableRules, rule.RuleID, false) {
+		return true
+	}
+	for _, tag := range rule.Tags {
+		if containsFold(s.EnableTags, tag, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the selection references rules that are
+// not defined by cfg.
+func (s RuleSelection) Validate(cfg Config) error {
+	for _, ids := range [][]string{s.EnableRules, s.DisableRules} {
+		for _, id := range ids {
+			if _, ok := cfg.Rules[id]; !ok {
+				return fmt.Errorf("rule %s not found in rules", id)
+			}
+		}
+	}
+	return nil
+}
+
+// Profile returns the profile with the given name. Names are not case
+// sensitive.
+func (c *Config) Profile(name string) (Profile, error) {
+	p, ok := c.Profiles[strings.ToLower(name)]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %s not found in config", name)
+	}
+	return p, nil
+}
+
+func containsFold(values []string, s string, fold bool) bool {
+	for _, v := range values {
+		if v == s || (fold && strings.EqualFold(v, s)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	viper.Reset()
+	viper.AddConfigPath(configPath)
+	viper.SetConfigName("profiles")
+	viper.SetConfigType("toml")
+	require.NoError(t, viper.ReadInConfig())
+
+	var vc ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+
+	ci, err := cfg.Profile("ci")
+	require.NoError(t, err)
+	redact, exitCode := uint(100), 2
+	assert.Equal(t, Profile{
+		Description:   "cloud keys only",
+		RuleSelection: RuleSelection{EnableTags: []string{"aws", "gcp"}},
+		Redact:        &redact,
+		ExitCode:      &exitCode,
+	}, ci)
+
+	audit, err := cfg.Profile("AUDIT")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"generic-api-key"}, audit.DisableRules)
+	assert.Nil(t, audit.Redact)
+
+	_, err = cfg.Profile("precommit")
+	assert.EqualError(t, err, "profile precommit not found in config")
+}
+
+func TestRuleSelection(t *testing.T) {
+	aws := Rule{RuleID: "aws-access-key", Tags: []string{"key", "AWS"}}
+	gcp := Rule{RuleID: "gcp-api-key", Tags: []string{"key", "GCP"}}
+	generic := Rule{RuleID: "generic-api-key", Tags: []string{"generic"}}
+
+	tests := []struct {
+		name      string
+		selection RuleSelection
+		selected  []string
+	}{
+		{
+			name:     "empty",
+			selected: []string{"aws-access-key", "gcp-api-key", "generic-api-key"},
+		},
+		{
+			name:      "enable tag",
+			selection: RuleSelection{EnableTags: []string{"aws"}},
+			selected:  []string{"aws-access-key"},
+		},
+		{
+			name:      "enable rule and tag",
+			selection: RuleSelection{EnableRules: []string{"generic-api-key"}, EnableTags: []string{"GCP"}},
+			selected:  []string{"gcp-api-key", "generic-api-key"},
+		},
+		{
+			name:      "disable rule",
+			selection: RuleSelection{DisableRules: []string{"generic-api-key"}},
+			selected:  []string{"aws-access-key", "gcp-api-key"},
+		},
+		{
+			name:      "disable tag wins over enable",
+			selection: RuleSelection{EnableTags: []string{"key"}, DisableTags: []string{"aws"}},
+			selected:  []string{"gcp-api-key"},
+		},
+	}
+	for _, tt := range tests {
+		var selected []string
+		for _, r := range []Rule{aws, gcp, generic} {
+			if tt.selection.Selects(r) {
+				selected = append(selected, r.RuleID)
+			}
+		}
+		assert.Equal(t, tt.selected, selected, tt.name)
+	}
+
+	cfg := Config{
+		Rules:        map[string]Rule{aws.RuleID: aws, gcp.RuleID: gcp, generic.RuleID: generic},
+		orderedRules: []string{aws.RuleID, gcp.RuleID, generic.RuleID},
+	}
+	cfg.FilterRules(RuleSelection{DisableTags: []string{"generic"}}.Selects)
+	assert.Equal(t, []Rule{aws, gcp}, cfg.OrderedRules())
+
+	cfg = Config{Rules: map[string]Rule{aws.RuleID: aws}}
+	assert.NoError(t, RuleSelection{EnableTags: []string{"unknown"}}.Validate(cfg))
+	assert.EqualError(t, RuleSelection{DisableRules: []string{"unknown"}}.Validate(cfg), "rule unknown not found in rules")
+}
+
+func TestRuleSelectionOverride(t *testing.T) {
+	profile := RuleSelection{
+		EnableTags:   []string{"aws", "gcp"},
+		DisableRules: []string{"generic-api-key"},
+	}
+
+	// the enable and disable sets given on the command line replace the
+	// profile's
+	flags := RuleSelection{EnableTags: []string{"azure"}}
+	assert.Equal(t, RuleSelection{
+		EnableTags:   []string{"azure"},
+		DisableRules: []string{"generic-api-key"},
+	}, profile.Override(flags))
+
+	assert.Equal(t, profile, profile.Override(RuleSelection{}))
+
+	// enabling a rule replaces the tags the profile enables, disabling a
+	// tag replaces the rules it disables
+	flags = RuleSelection{EnableRules: []string{"aws-access-key"}, DisableTags: []string{"test"}}
+	selection := profile.Override(flags)
+	assert.Equal(t, flags, selection)
+	assert.True(t, selection.Selects(Rule{RuleID: "aws-access-key", Tags: []string{"aws"}}))
+	assert.False(t, selection.Selects(Rule{RuleID: "aws-secret-key", Tags: []string{"aws"}}))
+}
@@ -37,7 +37,148 @@ type Rule struct {
 	// keyword(s) are in the content being scanned.
 	Keywords []string
 
-	// Allowlist allows a rule to be ignored for specific
-	// regexes, paths, and/or commits
+	// Severity is an optional severity reported with findings of this
+	// rule, ex: critical, high, medium, low or info.
+	Severity string
+
+	// Allowlists allow a rule to be ignored for specific regexes, paths,
+	// and/or commits. A finding is ignored if any of them matches.
+	Allowlists []Allowlist
+
+	// Allowlist is the first of Allowlists.
+	//
+	// Deprecated: a rule can have several allowlists, use Allowlists. An
+	// Allowlist set by code is used as the first of Allowlists, see
+	// Config.UpgradeAllowlists.
 	Allowlist Allowlist
+
+	// Validator is the name of an offline structural check, like a
+	// checksum or a decodable header, that a secret must pass to be
+	// reported. Leave empty to skip validation.
+	Validator string
+
+	// KeepInvalid reports secrets that fail the Validator check with an
+	// "invalid-structure" tag instead of dropping them.
+	KeepInvalid bool
+
+	// Verify is an optional HTTP request used to check whether a secret
+	// is live. It is only executed when verification is enabled.
+	Verify *Verify
+
+	// Tests are examples the rule must and must not detect. They are run
+	// by `gitleaks config test`.
+	Tests []RuleTest
+
+	// Source is where the rule was defined, ex: the path of a config file,
+	// an extend.url or "default config". Empty if unknown. Rules that were
+	// overridden list the overriding config too.
+	Source string
+
+	// set holds the config keys an override was loaded with, so it can
+	// set a field to its zero value. It is nil for rules built in code,
+	// whose overrides apply the fields that are not zero.
+	set map[string]bool
+}
+
+// RuleTest is a set of examples for a rule. Every true positive must be
+// detected by the rule and no false positive may be.
+type RuleTest struct {
+	// Description is an optional description of the test.
+	Description string
+
+	// TruePositives are inputs the rule must detect.
+	TruePositives []string
+
+	// FalsePositives are inputs the rule must not detect.
+	FalsePositives []string
+
+	// Secret is the secret expected to be extracted from every true
+	// positive. Leave empty to only check that a finding is reported.
+	Secret string
+
+	// Path is the file path the inputs are scanned as, which is needed
+	// to test rules with a path.
+	Path string
+}
+
+// Verify describes an HTTP request that checks whether a secret is active.
+// The placeholder {{secret}} is replaced with the secret in the URL,
+// headers and body.
+type Verify struct {
+	// Method is the HTTP method, GET if empty.
+	Method string
+
+	// URL is the endpoint to call.
+	URL string
+
+	// Headers are added to the request.
+	Headers map[string]string
+
+	// Body is sent as the request body if set.
+	Body string
+
+	// ExpectedStatus lists the status codes that mean the secret is
+	// active. Defaults to 200.
+	ExpectedStatus []int
+}
+
+// isOverride returns true if the rule has neither a regex nor a path. When
+// extending a config, such a rule is merged into the inherited rule with
+// the same ID instead of replacing it.
+func (r Rule) isOverride() bool {
+	return r.Regex == nil && r.Path == nil
+}
+
+// merge returns a copy of r with the fields set in override applied.
+// Allowlists are appended.
+func (r Rule) merge(override Rule) Rule {
+	if override.sets("description", override.Description != "") {
+		r.Description = override.Description
+	}
+	if override.sets("entropy", override.Entropy != 0) {
+		r.Entropy = override.Entropy
+	}
+	if override.sets("secretGroup", override.SecretGroup != 0) {
+		r.SecretGroup = override.SecretGroup
+	}
+	if override.sets("tags", len(override.Tags) != 0) {
+		r.Tags = override.Tags
+	}
+	if override.sets("keywords", len(override.Keywords) != 0) {
+		r.Keywords = override.Keywords
+	}
+	if override.sets("severity", override.Severity != "") {
+		r.Severity = override.Severity
+	}
+	if override.sets("validator", override.Validator != "") {
+		r.Validator = override.Validator
+	}
+	if override.sets("keepInvalid", override.KeepInvalid) {
+		r.KeepInvalid = override.KeepInvalid
+	}
+	if override.sets("verify", override.Verify != nil) {
+		r.Verify = override.Verify
+	}
+	if override.sets("tests", len(override.Tests) != 0) {
+		r.Tests = override.Tests
+	}
+	if override.Source != "" {
+		if r.Source == "" {
+			r.Source = override.Source
+		} else {
+			r.Source += ", overridden by " + override.Source
+		}
+	}
+
+	r.Allowlists = append(r.Allowlists[:len(r.Allowlists):len(r.Allowlists)], override.Allowlists...)
+	return r
+}
+
+// sets returns true if the rule sets the config key. Rules built in code
+// set the keys whose field is not zero, notZero.
+func (r Rule) sets(key string, notZero bool) bool {
+	if r.set == nil {
+		return notZero
+	}
+	return r.set[key]
 }
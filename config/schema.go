@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// JSONSchema returns a JSON Schema (draft-07) for config files in any of
+// the supported formats. It is generated from ViperConfig so it stays in
+// sync with what gitleaks loads. Property names are the lowerCamelCase
+// names used by the default config, ex: secretGroup or regexTarget.
+//
+// Nested tables do not allow unknown keys so that editors catch typos.
+// Keys are matched case sensitively by the schema while gitleaks ignores
+// their case.
+func JSONSchema() ([]byte, error) {
+	s := typeSchema(reflect.TypeOf(ViperConfig{}))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["title"] = "gitleaks config"
+	s["properties"].(map[string]interface{})["title"] = map[string]interface{}{"type": "string"}
+	// the root table is left open for keys used by other tools
+	delete(s, "additionalProperties")
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		addProperties(properties, t)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// addProperties adds the exported fields of the struct t to properties.
+// Fields squashed by mapstructure are flattened like viper decodes them.
+func addProperties(properties map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if f.Anonymous && strings.Contains(f.Tag.Get("mapstructure"), "squash") {
+			addProperties(properties, f.Type)
+			continue
+		}
+		properties[propertyName(f)] = typeSchema(f.Type)
+	}
+}
+
+// propertyName returns the config key of a field: its json tag if set,
+// otherwise its name in lowerCamelCase, ex: RegexTarget is regexTarget and
+// ID is id.
+func propertyName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	if strings.IndexFunc(f.Name, unicode.IsLower) == -1 {
+		return strings.ToLower(f.Name)
+	}
+	return strings.ToLower(f.Name[:1]) + f.Name[1:]
+}
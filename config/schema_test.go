@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := JSONSchema()
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("gitleaks.schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(committed), string(schema),
+		"gitleaks.schema.json is out of date, run `go run . config schema > config/gitleaks.schema.json`")
+}
+
+// TestJSONSchemaKeys checks that the keys of the default config and the
+// test configs are properties of the schema.
+func TestJSONSchemaKeys(t *testing.T) {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	configs := map[string]string{"gitleaks.toml": DefaultConfig}
+	for _, name := range []string{"formats.toml", "profiles.toml", "rule_tests.toml", "verify.toml", "extend_override.toml"} {
+		data, err := os.ReadFile(configPath + name)
+		require.NoError(t, err)
+		configs[name] = string(data)
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			tree, err := toml.Load(cfg)
+			require.NoError(t, err)
+			assertSchemaKeys(t, schema, tree.ToMap(), "")
+		})
+	}
+}
+
+func assertSchemaKeys(t *testing.T, schema map[string]interface{}, value interface{}, path string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for k, v := range value {
+			if s, ok := properties[k].(map[string]interface{}); ok {
+				assertSchemaKeys(t, s, v, path+"."+k)
+			} else if additional != nil {
+				assertSchemaKeys(t, additional, v, path+"."+k)
+			} else if schema["additionalProperties"] == false {
+				t.Errorf("%s.%s is not in the schema", path, k)
+			}
+		}
+	case []map[string]interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for _, item := range value {
+			assertSchemaKeys(t, items, item, path+"[]")
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for _, item := range value {
+			assertSchemaKeys(t, items, item, path+"[]")
+		}
+	}
+}
@@ -0,0 +1,573 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/viper"
+)
+
+// regexTargets are the accepted values of allowlist.regexTarget.
+var regexTargets = map[string]bool{
+	"":       true,
+	"secret": true,
+	"match":  true,
+	"line":   true,
+}
+
+// Problem is a mistake found in a config file.
+type Problem struct {
+	// File is the path of the config file.
+	File string
+
+	// Line is the line the problem was found on, 0 if unknown.
+	Line int
+
+	// RuleID is the ID of the rule the problem belongs to, if any.
+	RuleID string
+
+	// Message describes the problem.
+	Message string
+
+	// Warning is set for problems that do not prevent the config from
+	// loading and may be intended, like keywords that only appear next
+	// to a match.
+	Warning bool
+}
+
+// HasErrors returns true if any of the problems is not a warning.
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Problem) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.File)
+	if p.Line > 0 {
+		fmt.Fprintf(&sb, ":%d", p.Line)
+	}
+	sb.WriteString(": ")
+	if p.Warning {
+		sb.WriteString("warning: ")
+	}
+	if p.RuleID != "" {
+		fmt.Fprintf(&sb, "rule %s: ", p.RuleID)
+	}
+	sb.WriteString(p.Message)
+	return sb.String()
+}
+
+// ValidateFile reads and validates the config file at path. The format is
+// taken from the file extension, ex: .toml, .yaml or .json.
+func ValidateFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+		return ValidateType(path, data, ext[1:]), nil
+	}
+	return Validate(path, data), nil
+}
+
+// Validate checks a toml config for mistakes that would otherwise fail at
+// load time or silently disable rules. All problems found are returned.
+// Configs referenced by extend are not validated.
+func Validate(file string, data []byte) []Problem {
+	v := validator{file: file}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		var line, col int
+		msg := err.Error()
+		if _, scanErr := fmt.Sscanf(msg, "(%d, %d):", &line, &col); scanErr == nil {
+			msg = strings.TrimSpace(msg[strings.Index(msg, ":")+1:])
+		}
+		v.add(line, "", "invalid toml: %s", msg)
+		return v.problems
+	}
+	v.config(tomlTable{tree})
+	return v.problems
+}
+
+// ValidateType checks a config of the given type, ex: yaml or json, like
+// Validate. Problems in these formats are reported without a line.
+func ValidateType(file string, data []byte, configType string) []Problem {
+	v := validator{file: file}
+
+	parsed := viper.New()
+	parsed.SetConfigType(configType)
+	if err := parsed.ReadConfig(bytes.NewReader(data)); err != nil {
+		v.add(0, "", "invalid %s: %s", configType, err)
+		return v.problems
+	}
+	v.config(mapTable(parsed.AllSettings()))
+	return v.problems
+}
+
+type validator struct {
+	file     string
+	problems []Problem
+
+	// extends is set if the config extends another config, in which case
+	// rules without a regex and path override inherited rules.
+	extends bool
+}
+
+func (v *validator) add(line int, ruleID string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    line,
+		RuleID:  ruleID,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) warn(line int, ruleID string, format string, args ...interface{}) {
+	v.add(line, ruleID, format, args...)
+	v.problems[len(v.problems)-1].Warning = true
+}
+
+func (v *validator) config(t table) {
+	v.allowlists(t, "")
+
+	value, line, ok := t.get("rules")
+	if !ok {
+		return
+	}
+	if items, ok := value.([]interface{}); ok && len(items) == 0 {
+		return
+	}
+	rules, ok := value.([]table)
+	if !ok {
+		v.add(line, "", "rules must be an array of tables, use [[rules]]")
+		return
+	}
+	_, _, v.extends = t.get("extend")
+	seen := make(map[string]int)
+	for _, rule := range rules {
+		v.rule(rule, seen)
+	}
+}
+
+func (v *validator) rule(rule table, seen map[string]int) {
+	line := rule.line()
+	id, idLine, _ := v.str(rule, "id", "")
+	if id == "" {
+		v.add(line, "", "rule is missing an id")
+	} else if first, ok := seen[id]; ok {
+		if first > 0 {
+			v.add(idLine, id, "duplicate rule id, first defined on line %d", first)
+		} else {
+			v.add(idLine, id, "duplicate rule id")
+		}
+	} else {
+		seen[id] = idLine
+	}
+
+	regex, regexLine, hasRegex := v.str(rule, "regex", id)
+	path, pathLine, hasPath := v.str(rule, "path", id)
+	if !hasRegex && !hasPath && !v.extends {
+		v.add(line, id, "rule must define a regex, a path or both")
+	}
+
+	var re *regexp.Regexp
+	if hasRegex {
+		var err error
+		if re, err = regexp.Compile(regex); err != nil {
+			v.add(regexLine, id, "invalid regex: %s", err)
+		}
+	}
+	if hasPath {
+		if _, err := regexp.Compile(path); err != nil {
+			v.add(pathLine, id, "invalid path: %s", err)
+		}
+	}
+
+	if value, groupLine, ok := rule.get("secretGroup"); ok && re != nil {
+		if group, ok := toInt(value); ok && group > re.NumSubexp() {
+			v.add(groupLine, id, "secretGroup %d is larger than the %d groups in the regex", group, re.NumSubexp())
+		}
+	}
+
+	if name, line, ok := v.str(rule, "validator", id); ok && !KnownValidator(name) {
+		v.add(line, id, "unknown validator %q", name)
+	}
+
+	keywords, keywordsLine := v.strs(rule, "keywords", id)
+	if re != nil {
+		for _, k := range keywords {
+			if !keywordCanMatch(re, k) {
+				v.warn(keywordsLine, id, "keyword %q can never be part of a regex match", k)
+			}
+		}
+	}
+
+	v.allowlists(rule, id)
+}
+
+// allowlists validates the allowlist table and the allowlists array of
+// tables of t.
+func (v *validator) allowlists(t table, ruleID string) {
+	if value, line, ok := t.get("allowlist"); ok {
+		if allowlist, ok := value.(table); ok {
+			v.allowlist(allowlist, ruleID)
+		} else {
+			v.add(line, ruleID, "allowlist must be a table")
+		}
+	}
+	if value, line, ok := t.get("allowlists"); ok {
+		allowlists, ok := value.([]table)
+		if !ok {
+			if items, ok := value.([]interface{}); ok && len(items) == 0 {
+				return
+			}
+			v.add(line, ruleID, "allowlists must be an array of tables, use [[allowlists]] or [[rules.allowlists]]")
+			return
+		}
+		for _, allowlist := range allowlists {
+			v.allowlist(allowlist, ruleID)
+		}
+	}
+}
+
+func (v *validator) allowlist(allowlist table, ruleID string) {
+	if condition, line, ok := v.str(allowlist, "condition", ruleID); ok {
+		if _, err := parseMatchCondition(condition); err != nil {
+			v.add(line, ruleID, "%s", err)
+		}
+	}
+	if target, line, ok := v.str(allowlist, "regexTarget", ruleID); ok && !regexTargets[target] {
+		v.add(line, ruleID, "unknown allowlist regexTarget %q, expected secret, match or line", target)
+	}
+	for _, key := range []string{"regexes", "paths"} {
+		patterns, line := v.strs(allowlist, key, ruleID)
+		for _, p := range patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				v.add(line, ruleID, "invalid allowlist %s entry: %s", key, err)
+			}
+		}
+	}
+}
+
+// str returns the string value of key. Values of other types are reported
+// and treated as missing.
+func (v *validator) str(t table, key string, ruleID string) (string, int, bool) {
+	value, line, ok := t.get(key)
+	if !ok {
+		return "", line, false
+	}
+	s, ok := value.(string)
+	if !ok {
+		v.add(line, ruleID, "%s must be a string", key)
+		return "", line, false
+	}
+	return s, line, true
+}
+
+// strs returns the string array value of key. Values of other types are
+// reported and treated as missing.
+func (v *validator) strs(t table, key string, ruleID string) ([]string, int) {
+	value, line, ok := t.get(key)
+	if !ok {
+		return nil, line
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		v.add(line, ruleID, "%s must be an array of strings", key)
+		return nil, line
+	}
+	var res []string
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			v.add(line, ruleID, "%s must be an array of strings", key)
+			return nil, line
+		}
+		res = append(res, s)
+	}
+	return res, line
+}
+
+// table is a table of a parsed config, independent of its format.
+type table interface {
+	// get finds key ignoring case, like viper does when loading the
+	// config, and returns its value and line. Tables are returned as
+	// table and arrays of tables as []table.
+	get(key string) (interface{}, int, bool)
+
+	// line returns the line the table starts on, 0 if unknown.
+	line() int
+}
+
+// tomlTable is a table of a toml config, which knows its lines.
+type tomlTable struct {
+	tree *toml.Tree
+}
+
+func (t tomlTable) get(key string) (interface{}, int, bool) {
+	for _, k := range t.tree.Keys() {
+		if !strings.EqualFold(k, key) {
+			continue
+		}
+		line := t.tree.GetPosition(k).Line
+		switch value := t.tree.Get(k).(type) {
+		case *toml.Tree:
+			return tomlTable{value}, line, true
+		case []*toml.Tree:
+			tables := make([]table, len(value))
+			for i, tree := range value {
+				tables[i] = tomlTable{tree}
+			}
+			return tables, line, true
+		default:
+			return value, line, true
+		}
+	}
+	return nil, t.line(), false
+}
+
+func (t tomlTable) line() int {
+	return t.tree.Position().Line
+}
+
+// mapTable is a table of a yaml or json config as decoded by viper, which
+// does not know its lines.
+type mapTable map[string]interface{}
+
+func (t mapTable) get(key string) (interface{}, int, bool) {
+	for k, value := range t {
+		if !strings.EqualFold(k, key) {
+			continue
+		}
+		if m, ok := stringMap(value); ok {
+			return m, 0, true
+		}
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			return value, 0, true
+		}
+		tables := make([]table, 0, len(items))
+		for _, item := range items {
+			m, ok := stringMap(item)
+			if !ok {
+				return value, 0, true
+			}
+			tables = append(tables, m)
+		}
+		return tables, 0, true
+	}
+	return nil, 0, false
+}
+
+func (t mapTable) line() int {
+	return 0
+}
+
+// stringMap returns value as a mapTable if it is a map. yaml decodes the
+// tables in arrays with interface{} keys.
+func stringMap(value interface{}) (mapTable, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return mapTable(m), true
+	case map[interface{}]interface{}:
+		res := make(mapTable, len(m))
+		for k, v := range m {
+			res[fmt.Sprint(k)] = v
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// toInt returns value as an int if it is a number without a fraction.
+// toml decodes integers as int64, yaml as int and json as float64.
+func toInt(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// maxRegexPaths limits how many alternatives keywordCanMatch expands
+// before giving up.
+const maxRegexPaths = 1024
+
+// regexElem is a position in a simplified regex. A gap matches any number
+// of runes from the set, other elements match exactly one rune.
+type regexElem struct {
+	match func(r rune) bool
+	gap   bool
+}
+
+// keywordCanMatch reports whether a keyword can be a substring of some
+// match of re, ignoring case like the keyword prefilter does. The check
+// over-approximates what re can match, so it only returns false for
+// keywords that are never part of a match. Regexes that are too complex
+// to expand are assumed to match.
+func keywordCanMatch(re *regexp.Regexp, keyword string) bool {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return true
+	}
+	paths, ok := regexPaths(parsed.Simplify())
+	if !ok {
+		return true
+	}
+	keyword = strings.ToLower(keyword)
+	for _, path := range paths {
+		if pathContains(path, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// regexPaths flattens re into sequences of elements, one per alternative.
+func regexPaths(re *syntax.Regexp) ([][]regexElem, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		var path []regexElem
+		for _, r := range re.Rune {
+			path = append(path, regexElem{match: foldMatch(r)})
+		}
+		return [][]regexElem{path}, true
+	case syntax.OpCharClass:
+		return [][]regexElem{{{match: classMatch(re.Rune)}}}, true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return [][]regexElem{{{match: anyMatch}}}, true
+	case syntax.OpCapture:
+		return regexPaths(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		// a repeated single rune becomes a gap of that rune, anything
+		// more complex becomes a gap of any rune
+		sub := re.Sub[0]
+		for sub.Op == syntax.OpCapture {
+			sub = sub.Sub[0]
+		}
+		match := anyMatch
+		if paths, ok := regexPaths(sub); ok && len(paths) == 1 && len(paths[0]) == 1 && !paths[0][0].gap {
+			match = paths[0][0].match
+		}
+		return [][]regexElem{{{match: match, gap: true}}}, true
+	case syntax.OpConcat:
+		paths := [][]regexElem{{}}
+		for _, sub := range re.Sub {
+			subPaths, ok := regexPaths(sub)
+			if !ok || len(paths)*len(subPaths) > maxRegexPaths {
+				return nil, false
+			}
+			var next [][]regexElem
+			for _, p := range paths {
+				for _, s := range subPaths {
+					joined := make([]regexElem, 0, len(p)+len(s))
+					next = append(next, append(append(joined, p...), s...))
+				}
+			}
+			paths = next
+		}
+		return paths, true
+	case syntax.OpAlternate:
+		var paths [][]regexElem
+		for _, sub := range re.Sub {
+			subPaths, ok := regexPaths(sub)
+			if !ok || len(paths)+len(subPaths) > maxRegexPaths {
+				return nil, false
+			}
+			paths = append(paths, subPaths...)
+		}
+		return paths, true
+	case syntax.OpNoMatch:
+		return nil, true
+	default:
+		// empty matches, anchors and word boundaries match no runes
+		return [][]regexElem{{}}, true
+	}
+}
+
+// pathContains reports whether some string matched by path contains
+// keyword. The keyword may start and end anywhere in the path.
+func pathContains(path []regexElem, keyword string) bool {
+	// states are the indexes of the next element to match
+	states := make(map[int]bool)
+	for i := 0; i <= len(path); i++ {
+		states[i] = true
+	}
+	for _, r := range keyword {
+		next := make(map[int]bool)
+		for i := range closure(path, states) {
+			if i == len(path) || !path[i].match(r) {
+				continue
+			}
+			if path[i].gap {
+				next[i] = true
+			} else {
+				next[i+1] = true
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		states = next
+	}
+	return true
+}
+
+// closure adds the states reachable by skipping gaps.
+func closure(path []regexElem, states map[int]bool) map[int]bool {
+	res := make(map[int]bool, len(states))
+	for i := range states {
+		for ; ; i++ {
+			res[i] = true
+			if i == len(path) || !path[i].gap {
+				break
+			}
+		}
+	}
+	return res
+}
+
+func anyMatch(rune) bool { return true }
+
+// foldMatch matches r in any case.
+func foldMatch(r rune) func(rune) bool {
+	return func(c rune) bool {
+		return unicode.ToLower(c) == unicode.ToLower(r)
+	}
+}
+
+// classMatch matches runes in the character class ranges in any case.
+func classMatch(ranges []rune) func(rune) bool {
+	return func(c rune) bool {
+		for f := c; ; {
+			for i := 0; i+1 < len(ranges); i += 2 {
+				if ranges[i] <= f && f <= ranges[i+1] {
+					return true
+				}
+			}
+			if f = unicode.SimpleFold(f); f == c {
+				return false
+			}
+		}
+	}
+}
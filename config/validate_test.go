@@ -0,0 +1,106 @@
+package config
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	path := configPath + "invalid.toml"
+	problems, err := ValidateFile(path)
+	require.NoError(t, err)
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		path + ":4: unknown allowlist regexTarget \"commit\", expected secret, match or line",
+		path + ":5: invalid allowlist paths entry: error parsing regexp: missing closing ): `(unclosed`",
+		path + ":7: rule no-regex-or-path: rule must define a regex, a path or both",
+		path + ":14: warning: rule aws-access-key: keyword \"aws_key\" can never be part of a regex match",
+		path + ":17: rule aws-access-key: duplicate rule id, first defined on line 12",
+		path + ":22: rule bad-regex: invalid regex: error parsing regexp: invalid named capture: `(?P<secret[a-z]+`",
+		path + ":27: rule bad-secret-group: secretGroup 2 is larger than the 1 groups in the regex",
+		path + ":34: rule bad-allowlist: unknown allowlist regexTarget \"secrets\", expected secret, match or line",
+		path + ":35: rule bad-allowlist: invalid allowlist regexes entry: error parsing regexp: invalid character class range: `z-a`",
+		path + ":37: rule bad-allowlist: unknown allowlist condition \"XOR\", expected AND or OR",
+		path + ":43: rule bad-validator: unknown validator \"crc\"",
+	}, got)
+	assert.True(t, HasErrors(problems))
+}
+
+func TestValidateFormats(t *testing.T) {
+	for _, name := range []string{"invalid.yaml", "invalid.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := configPath + name
+			problems, err := ValidateFile(path)
+			require.NoError(t, err)
+
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			assert.Equal(t, []string{
+				path + ": unknown allowlist regexTarget \"commit\", expected secret, match or line",
+				path + ": invalid allowlist paths entry: error parsing regexp: missing closing ): `(unclosed`",
+				path + ": rule no-regex-or-path: rule must define a regex, a path or both",
+				path + ": warning: rule aws-access-key: keyword \"aws_key\" can never be part of a regex match",
+				path + ": rule aws-access-key: duplicate rule id",
+				path + ": rule bad-regex: invalid regex: error parsing regexp: invalid named capture: `(?P<secret[a-z]+`",
+				path + ": rule bad-secret-group: secretGroup 2 is larger than the 1 groups in the regex",
+				path + ": rule bad-allowlist: unknown allowlist regexTarget \"secrets\", expected secret, match or line",
+				path + ": rule bad-allowlist: invalid allowlist regexes entry: error parsing regexp: invalid character class range: `z-a`",
+			}, got)
+		})
+	}
+}
+
+func TestValidateDefaultConfig(t *testing.T) {
+	problems := Validate("gitleaks.toml", []byte(DefaultConfig))
+	assert.False(t, HasErrors(problems), problems)
+}
+
+func TestValidateInvalidToml(t *testing.T) {
+	problems := Validate("broken.toml", []byte("[[rules]]\nid = \"a\"\nregex = = \"b\"\n"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, 3, problems[0].Line)
+}
+
+func TestKeywordCanMatch(t *testing.T) {
+	tests := []struct {
+		regex    string
+		keyword  string
+		expected bool
+	}{
+		{`AKIA[0-9A-Z]{16}`, "akia", true},
+		{`AKIA[0-9A-Z]{16}`, "aws", true},
+		{`AKIA[0-9A-Z]{16}`, "aws_key", false},
+		{`(?i)(?:key|api|token)[\s=]+([a-z0-9]{32})`, "token", true},
+		{`(?i)(?:key|api|token)[\s=]+([a-z0-9]{32})`, "secret", true},
+		{`(?i)(?:key|api|token)[\s=]+([a-z0-9]{32})`, "token:", false},
+		{`(ghu|ghs)_[0-9a-zA-Z]{36}`, "ghs_", true},
+		{`(ghu|ghs)_[0-9a-zA-Z]{36}`, "ghp_", false},
+		{`SK[0-9a-fA-F]{32}`, "twilio", false},
+		{`-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY`, "-----begin", true},
+		// keywords may span literals and character classes
+		{`xox[baprs]-[0-9a-zA-Z]{10,48}`, "xoxb", true},
+		{`hvs\.[a-z0-9_-]{90}`, "s.abc", true},
+		{`hvs\.[a-z0-9_-]{90}`, "s.ABC", true},
+		{`hvs\.[a-z0-9_-]{90}`, "s..", false},
+		{`pass.*word`, "passXYZword", true},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(tt.regex)
+		assert.Equal(t, tt.expected, keywordCanMatch(re, tt.keyword), "%s %s", tt.regex, tt.keyword)
+	}
+}
+
+func TestValidateOverride(t *testing.T) {
+	problems, err := ValidateFile(configPath + "extend_override.toml")
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
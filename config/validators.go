@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hash/crc32"
+	"strings"
+	"sync"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Validator checks the structure of a secret without making any network
+// calls. It returns true if the secret is well-formed.
+type Validator func(secret string) bool
+
+var (
+	validatorsMu sync.RWMutex
+	// validators are the names rules can use as Validator, the built-in
+	// validators and the ones registered with RegisterValidator.
+	validators = map[string]Validator{
+		"github-checksum": validGitHubToken,
+		"npm-checksum":    validNpmToken,
+		"jwt":             validJWT,
+	}
+)
+
+// RegisterValidator makes a validator available to rules under the given
+// name. Registering a name twice replaces the previous validator. Configs
+// are checked against the registered names, so validators must be
+// registered before the config is loaded.
+func RegisterValidator(name string, v Validator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = v
+}
+
+// KnownValidator reports whether rules can use name as Validator.
+func KnownValidator(name string) bool {
+	_, ok := LookupValidator(name)
+	return ok
+}
+
+// LookupValidator returns the validator registered under name.
+func LookupValidator(name string) (Validator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	v, ok := validators[name]
+	return v, ok
+}
+
+// validGitHubToken checks the CRC32 checksum embedded in the last six
+// characters of ghp_, gho_, ghu_, ghs_ and ghr_ tokens.
+func validGitHubToken(secret string) bool {
+	for _, prefix := range []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_"} {
+		if strings.HasPrefix(secret, prefix) {
+			return validChecksumToken(strings.TrimPrefix(secret, prefix))
+		}
+	}
+	return false
+}
+
+// validNpmToken checks the CRC32 checksum embedded in npm_ access tokens,
+// which use the same scheme as GitHub tokens.
+func validNpmToken(secret string) bool {
+	if !strings.HasPrefix(secret, "npm_") {
+		return false
+	}
+	return validChecksumToken(strings.TrimPrefix(secret, "npm_"))
+}
+
+// validChecksumToken checks a 36 character token body made of 30 random
+// characters followed by the base62 encoded CRC32 of those characters.
+func validChecksumToken(body string) bool {
+	if len(body) != 36 {
+		return false
+	}
+	return body[30:] == tokenChecksum(body[:30])
+}
+
+// tokenChecksum returns the CRC32 of s, base62 encoded and left padded
+// with zeros to six characters.
+func tokenChecksum(s string) string {
+	n := crc32.ChecksumIEEE([]byte(s))
+	var buf [6]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(buf[:])
+}
+
+// validJWT checks that a secret has three dot separated parts and that the
+// header and payload are base64url encoded JSON objects. The header must
+// declare an algorithm.
+func validJWT(secret string) bool {
+	parts := strings.Split(secret, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if !decodeJWTPart(parts[0], &header) || header.Alg == "" {
+		return false
+	}
+	var payload map[string]interface{}
+	return decodeJWTPart(parts[1], &payload)
+}
+
+func decodeJWTPart(part string, v interface{}) bool {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
@@ -0,0 +1,159 @@
+package detect
+
+import (
+	"strings"
+)
+
+const (
+	gitleaksAllowStartSignature = "gitleaks:allow-start"
+	gitleaksAllowEndSignature   = "gitleaks:allow-end"
+)
+
+// allowDirective is a parsed gitleaks:allow comment, ex:
+//
+//	gitleaks:allow rule=aws-access-token,github-pat reason="test fixture"
+//
+// A directive without a rule argument applies to every rule.
+type allowDirective struct {
+	signature string
+	rules     []string
+	reason    string
+}
+
+// allows returns true if the directive applies to the given rule.
+func (a allowDirective) allows(ruleID string) bool {
+	if len(a.rules) == 0 {
+		return true
+	}
+	for _, r := range a.rules {
+		if r == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// allowBlock is a region of a fragment between gitleaks:allow-start and
+// gitleaks:allow-end markers. start and end are byte offsets into the
+// fragment's raw content.
+type allowBlock struct {
+	start     int
+	end       int
+	directive allowDirective
+}
+
+// lineAllowDirective returns the first gitleaks:allow directive in line
+// that applies to ruleID. Block markers are not line directives.
+func lineAllowDirective(line string, ruleID string) (allowDirective, bool) {
+	for i := strings.Index(line, gitleaksAllowSignature); i != -1; {
+		rest := line[i+len(gitleaksAllowSignature):]
+		if !strings.HasPrefix(rest, "-start") && !strings.HasPrefix(rest, "-end") {
+			directive := parseAllowArgs(gitleaksAllowSignature, rest)
+			if directive.allows(ruleID) {
+				return directive, true
+			}
+		}
+		next := strings.Index(rest, gitleaksAllowSignature)
+		if next == -1 {
+			break
+		}
+		i += len(gitleaksAllowSignature) + next
+	}
+	return allowDirective{}, false
+}
+
+// findAllowBlocks returns the gitleaks:allow-start/gitleaks:allow-end
+// regions of raw. A start marker without a matching end marker covers
+// the remainder of raw, its directive is returned so the block can be
+// continued in the next chunk of the same file. open is the directive of
+// a block continued from the previous chunk, or nil.
+func findAllowBlocks(raw string, open *allowDirective) ([]allowBlock, *allowDirective) {
+	var (
+		blocks []allowBlock
+		block  *allowBlock
+		offset int
+	)
+	if open != nil {
+		block = &allowBlock{directive: *open}
+	}
+	for _, line := range strings.SplitAfter(raw, "\n") {
+		if block == nil {
+			if i := strings.Index(line, gitleaksAllowStartSignature); i != -1 {
+				block = &allowBlock{
+					start:     offset,
+					directive: parseAllowArgs(gitleaksAllowStartSignature, line[i+len(gitleaksAllowStartSignature):]),
+				}
+			}
+		}
+		offset += len(line)
+		if block != nil && strings.Contains(line, gitleaksAllowEndSignature) {
+			block.end = offset
+			blocks = append(blocks, *block)
+			block = nil
+		}
+	}
+	if block != nil {
+		block.end = len(raw)
+		blocks = append(blocks, *block)
+		return blocks, &block.directive
+	}
+	return blocks, nil
+}
+
+// blockAllowDirective returns the directive of the first allow block that
+// contains index and applies to ruleID.
+func blockAllowDirective(blocks []allowBlock, index int, ruleID string) (allowDirective, bool) {
+	for _, b := range blocks {
+		if b.start <= index && index < b.end && b.directive.allows(ruleID) {
+			return b.directive, true
+		}
+	}
+	return allowDirective{}, false
+}
+
+// parseAllowArgs parses the key=value arguments that follow a signature.
+// Values may be double quoted. Parsing stops at the first token that is
+// not an argument, like the end of a comment.
+func parseAllowArgs(signature string, s string) allowDirective {
+	directive := allowDirective{signature: signature}
+	if s == "" || (s[0] != ' ' && s[0] != '\t') {
+		return directive
+	}
+	for {
+		s = strings.TrimLeft(s, " \t")
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \t\r\n") {
+			return directive
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end == -1 {
+				return directive
+			}
+			value = s[1 : end+1]
+			s = s[end+2:]
+		} else {
+			end := strings.IndexAny(s, " \t\r\n")
+			if end == -1 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+
+		switch key {
+		case "rule":
+			for _, r := range strings.Split(value, ",") {
+				if r = strings.TrimSpace(r); r != "" {
+					directive.rules = append(directive.rules, r)
+				}
+			}
+		case "reason":
+			directive.reason = value
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package detect
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,46 +8,116 @@ import (
 	"github.com/zricethezav/gitleaks/v8/report"
 )
 
+// Baseline is an index of previously reported findings keyed by rule, file
+// and secret hash, see report.Finding.BaselineKey.
+type Baseline struct {
+	index map[string][]report.Finding
+
+	// partial is set for baselines read from csv or sarif reports, which
+	// don't record all the fields of findings.
+	partial bool
+}
+
+// NewBaseline indexes findings so they can be looked up without scanning
+// the whole baseline.
+func NewBaseline(findings []report.Finding) *Baseline {
+	b := &Baseline{index: make(map[string][]report.Finding, len(findings))}
+	for _, f := range findings {
+		key := f.BaselineKey()
+		b.index[key] = append(b.index[key], f)
+	}
+	return b
+}
+
+// Contains returns true if finding is part of the baseline. If
+// ignoreLocation is set, a finding matches a baseline entry with the same
+// rule, file and secret even if its line, column or commit changed.
+//
+// Entries of baselines read from csv or sarif reports don't record all
+// the fields of a finding, so they match a finding found at the same
+// location of the same commit. Entries without a secret, like sarif
+// results without a snippet, match any secret of the rule in the file.
+func (b *Baseline) Contains(finding report.Finding, ignoreLocation bool) bool {
+	candidates := b.index[finding.BaselineKey()]
+	if finding.SecretHash != "" {
+		// baselines written by older versions don't record the hash of
+		// redacted secrets, so they are indexed by the redacted secret
+		legacy := finding
+		legacy.SecretHash = ""
+		candidates = append(candidates[:len(candidates):len(candidates)], b.index[legacy.BaselineKey()]...)
+	}
+	if b.partial {
+		noSecret := report.Finding{RuleID: finding.RuleID, File: finding.File}
+		candidates = append(candidates[:len(candidates):len(candidates)], b.index[noSecret.BaselineKey()]...)
+	}
+	if ignoreLocation {
+		return len(candidates) != 0
+	}
+	for _, c := range candidates {
+		if b.partial && sameLocation(finding, c) || sameFinding(finding, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameLocation(finding report.Finding, b report.Finding) bool {
+	return finding.Commit == b.Commit &&
+		finding.StartLine == b.StartLine &&
+		finding.EndLine == b.EndLine &&
+		finding.StartColumn == b.StartColumn &&
+		finding.EndColumn == b.EndColumn
+}
+
 func IsNew(finding report.Finding, baseline []report.Finding) bool {
-	// Explicitly testing each property as it gives significantly better performance in comparison to cmp.Equal(). Drawback is that
-	// the code requires maintenance if/when the Finding struct changes
 	for _, b := range baseline {
-
-		if finding.Author == b.Author &&
-			finding.Commit == b.Commit &&
-			finding.Date == b.Date &&
-			finding.Description == b.Description &&
-			finding.Email == b.Email &&
-			finding.EndColumn == b.EndColumn &&
-			finding.EndLine == b.EndLine &&
-			finding.Entropy == b.Entropy &&
-			finding.File == b.File &&
-			// Omit checking finding.Fingerprint - if the format of the fingerprint changes, the users will see unexpected behaviour
-			finding.Match == b.Match &&
-			finding.Message == b.Message &&
-			finding.RuleID == b.RuleID &&
-			finding.Secret == b.Secret &&
-			finding.StartColumn == b.StartColumn &&
-			finding.StartLine == b.StartLine {
+		if sameFinding(finding, b) {
 			return false
 		}
 	}
 	return true
 }
 
+func sameFinding(finding report.Finding, b report.Finding) bool {
+	// Explicitly testing each property as it gives significantly better performance in comparison to cmp.Equal(). Drawback is that
+	// the code requires maintenance if/when the Finding struct changes
+	return finding.Author == b.Author &&
+		finding.Commit == b.Commit &&
+		finding.Date == b.Date &&
+		finding.Description == b.Description &&
+		finding.Email == b.Email &&
+		finding.EndColumn == b.EndColumn &&
+		finding.EndLine == b.EndLine &&
+		finding.Entropy == b.Entropy &&
+		finding.File == b.File &&
+		// Omit checking finding.Fingerprint - if the format of the fingerprint changes, the users will see unexpected behaviour
+		finding.Match == b.Match &&
+		finding.Message == b.Message &&
+		finding.RuleID == b.RuleID &&
+		finding.Secret == b.Secret &&
+		finding.StartColumn == b.StartColumn &&
+		finding.StartLine == b.StartLine
+}
+
 func LoadBaseline(baselinePath string) ([]report.Finding, error) {
+	previousFindings, _, err := loadBaseline(baselinePath)
+	return previousFindings, err
+}
+
+// loadBaseline reads the baseline at baselinePath and returns its findings
+// and report format.
+func loadBaseline(baselinePath string) ([]report.Finding, string, error) {
 	bytes, err := os.ReadFile(baselinePath)
 	if err != nil {
-		return nil, fmt.Errorf("could not open %s", baselinePath)
+		return nil, "", fmt.Errorf("could not open %s", baselinePath)
 	}
 
-	var previousFindings []report.Finding
-	err = json.Unmarshal(bytes, &previousFindings)
+	previousFindings, format, err := report.ReadFormat(bytes)
 	if err != nil {
-		return nil, fmt.Errorf("the format of the file %s is not supported", baselinePath)
+		return nil, "", fmt.Errorf("the format of the file %s is not supported", baselinePath)
 	}
 
-	return previousFindings, nil
+	return previousFindings, format, nil
 }
 
 func (d *Detector) AddBaseline(baselinePath string, source string) error {
@@ -68,12 +137,13 @@ func (d *Detector) AddBaseline(baselinePath string, source string) error {
 			return err
 		}
 
-		baseline, err := LoadBaseline(baselinePath)
+		baseline, format, err := loadBaseline(baselinePath)
 		if err != nil {
 			return err
 		}
 
-		d.baseline = baseline
+		d.Baseline = NewBaseline(baseline)
+		d.Baseline.partial = format == "csv" || format == "sarif"
 		baselinePath = relativeBaseline
 
 	}
@@ -1,15 +1,16 @@
 package detect
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/metrics"
 	"github.com/zricethezav/gitleaks/v8/report"
 
 	ahocorasick "github.com/BobuSumisu/aho-corasick"
@@ -21,6 +22,7 @@ import (
 
 const (
 	gitleaksAllowSignature = "gitleaks:allow"
+	allowlistDirective     = "allowlist"
 	chunkSize              = 10 * 1_000 // 10kb
 )
 
@@ -34,6 +36,11 @@ type Detector struct {
 	// without calling `detector.Start(cmd *cobra.Command)`
 	Redact uint
 
+	// HashSecrets records the SHA-256 of secrets in the SecretHash field
+	// of findings before they are redacted, so redacted reports can be
+	// used as baselines.
+	HashSecrets bool
+
 	// verbose is a flag to print findings
 	Verbose bool
 
@@ -49,10 +56,33 @@ type Detector struct {
 	// IgnoreGitleaksAllow is a flag to ignore gitleaks:allow comments.
 	IgnoreGitleaksAllow bool
 
+	// Verifier checks whether secrets are live for rules that define a
+	// verify request. Verification is disabled when nil.
+	Verifier Verifier
+
+	// Baseline holds known findings that should not be reported again.
+	Baseline *Baseline
+
+	// OnFinding is called with every reported finding as soon as it is
+	// found, which allows streaming findings while a scan is running.
+	// Calls are serialized.
+	OnFinding func(report.Finding)
+
+	// Stats collects scan statistics when set.
+	Stats *Stats
+
+	// BaselineIgnoreLocation matches baseline findings by rule, file and
+	// secret only, so findings whose line moved are not reported again.
+	BaselineIgnoreLocation bool
+
 	// commitMap is used to keep track of commits that have been scanned.
 	// This is only used for logging purposes and git scans.
 	commitMap map[string]bool
 
+	// commitMutex is to prevent concurrent access to commitMap, which
+	// ScanStats reads while a scan may be running.
+	commitMutex sync.Mutex
+
 	// findingMutex is to prevent concurrent access to the
 	// findings slice when adding findings.
 	findingMutex *sync.Mutex
@@ -62,18 +92,40 @@ type Detector struct {
 	// report.
 	findings []report.Finding
 
+	// published is the number of findings that were redacted and
+	// published, the others wait to be verified
+	published int
+
+	// suppressions is a slice of findings that were suppressed by
+	// gitleaks:allow directives, kept so they can be audited.
+	suppressions []report.Suppression
+
+	// allowedFiles are the files skipped by an allowlist with a
+	// description that are in suppressions, so the chunks of a file are
+	// only recorded once.
+	allowedFiles map[string]bool
+
 	// prefilter is a ahocorasick struct used for doing efficient string
 	// matching given a set of words (keywords from the rules in the config)
 	prefilter ahocorasick.Trie
 
-	// a list of known findings that should be ignored
-	baseline []report.Finding
-
 	// path to baseline
 	baselinePath string
 
-	// gitleaksIgnore
-	gitleaksIgnore map[string]bool
+	// excludedPaths are absolute paths of files written by gitleaks during
+	// the scan, like streamed reports, that must not be scanned
+	excludedPaths map[string]bool
+
+	// nestedConfigs are configs of subdirectories of the source, deepest
+	// directories first
+	nestedConfigs []*nestedConfig
+
+	// gitleaksIgnore holds .gitleaksignore entries keyed by fingerprint
+	gitleaksIgnore map[string]ignoreEntry
+
+	// gitleaksIgnoreSecrets holds .gitleaksignore entries keyed by the
+	// SHA-256 of the secret they ignore
+	gitleaksIgnoreSecrets map[string][]ignoreEntry
 
 	// Sema (https://github.com/fatih/semgroup) controls the concurrency
 	Sema *semgroup.Group
@@ -98,18 +150,32 @@ type Fragment struct {
 	// keywords is a map of all the keywords contain within the contents
 	// of this fragment
 	keywords map[string]bool
+
+	// config is the config that applies to the fragment's file
+	config *config.Config
+
+	// allowBlocks are the gitleaks:allow-start/end regions of the fragment.
+	// Blocks only span the fragment, so for git scans a block must start
+	// in the same hunk as the secrets it allows.
+	allowBlocks []allowBlock
+
+	// explain records the decision path of a rule, see Detector.Explain
+	explain *Explanation
 }
 
 // NewDetector creates a new detector with the given config
 func NewDetector(cfg config.Config) *Detector {
+	cfg.UpgradeAllowlists()
 	return &Detector{
-		commitMap:      make(map[string]bool),
-		gitleaksIgnore: make(map[string]bool),
-		findingMutex:   &sync.Mutex{},
-		findings:       make([]report.Finding, 0),
-		Config:         cfg,
-		prefilter:      *ahocorasick.NewTrieBuilder().AddStrings(cfg.Keywords).Build(),
-		Sema:           semgroup.NewGroup(context.Background(), 40),
+		commitMap:             make(map[string]bool),
+		gitleaksIgnore:        make(map[string]ignoreEntry),
+		gitleaksIgnoreSecrets: make(map[string][]ignoreEntry),
+		excludedPaths:         make(map[string]bool),
+		findingMutex:          &sync.Mutex{},
+		findings:              make([]report.Finding, 0),
+		Config:                cfg,
+		prefilter:             *ahocorasick.NewTrieBuilder().AddStrings(cfg.Keywords).Build(),
+		Sema:                  semgroup.NewGroup(context.Background(), 40),
 	}
 }
 
@@ -125,35 +191,13 @@ func NewDetectorDefaultConfig() (*Detector, error) {
 	if err != nil {
 		return nil, err
 	}
-	cfg, err := vc.Translate()
+	cfg, err := vc.TranslateFrom(config.DefaultSource)
 	if err != nil {
 		return nil, err
 	}
 	return NewDetector(cfg), nil
 }
 
-func (d *Detector) AddGitleaksIgnore(gitleaksIgnorePath string) error {
-	log.Debug().Msgf("found .gitleaksignore file: %s", gitleaksIgnorePath)
-	file, err := os.Open(gitleaksIgnorePath)
-
-	if err != nil {
-		return err
-	}
-
-	// https://github.com/securego/gosec/issues/512
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Warn().Msgf("Error closing .gitleaksignore file: %s\n", err)
-		}
-	}()
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		d.gitleaksIgnore[scanner.Text()] = true
-	}
-	return nil
-}
-
 // DetectBytes scans the given bytes and returns a list of findings
 func (d *Detector) DetectBytes(content []byte) []report.Finding {
 	return d.DetectString(string(content))
@@ -168,61 +212,130 @@ func (d *Detector) DetectString(content string) []report.Finding {
 
 // Detect scans the given fragment and returns a list of findings
 func (d *Detector) Detect(fragment Fragment) []report.Finding {
-	var findings []report.Finding
+	findings, suppressions := d.detect(fragment)
+	for _, s := range suppressions {
+		d.addSuppression(s)
+	}
+	d.verifyFindings(findings)
+	for i := range findings {
+		if d.HashSecrets {
+			findings[i].HashSecret()
+		}
+		if d.Redact > 0 {
+			findings[i].Redact(d.Redact)
+		}
+	}
+	return findings
+}
+
+// detect scans the given fragment and returns a list of findings and a
+// list of findings suppressed by gitleaks:allow directives. Callers are
+// responsible for adjusting the location of both. Suppressions are
+// redacted, findings are not so they can be verified, see addFinding.
+func (d *Detector) detect(fragment Fragment) ([]report.Finding, []report.Suppression) {
+	var (
+		findings     []report.Finding
+		suppressions []report.Suppression
+	)
 
 	// initiate fragment keywords
 	fragment.keywords = make(map[string]bool)
-
-	// check if filepath is allowed
-	if fragment.FilePath != "" && (d.Config.Allowlist.PathAllowed(fragment.FilePath) ||
-		fragment.FilePath == d.Config.Path || (d.baselinePath != "" && fragment.FilePath == d.baselinePath)) {
-		return findings
+	cfg, prefilter := d.scanConfig(fragment.FilePath)
+	fragment.config = cfg
+
+	// check if filepath or commit is allowed
+	if a := fileAllowlist(cfg.Allowlists, fragment); a != nil {
+		log.Trace().Msgf("skipping %s, allowed by %s", fragment.FilePath, allowlistName(a))
+		fragment.explain.check(false, "config allowlists", func() string { return fmt.Sprintf("%s ignores the path or commit", allowlistName(a)) })
+		d.addFileSuppression(fragment, "", a)
+		return findings, suppressions
+	}
+	fragment.explain.check(true, "config allowlists", func() string { return "no allowlist ignores the path or commit" })
+	if fragment.FilePath != "" && (fragment.FilePath == d.Config.Path || (d.baselinePath != "" && fragment.FilePath == d.baselinePath) ||
+		d.excluded(fragment.FilePath) || d.nestedConfigPath(fragment.FilePath)) {
+		fragment.explain.check(false, "excluded", func() string { return "the file is a config, baseline or report of gitleaks" })
+		return findings, suppressions
 	}
 
 	// add newline indices for location calculation in detectRule
 	fragment.newlineIndices = regexp.MustCompile("\n").FindAllStringIndex(fragment.Raw, -1)
 
+	// find gitleaks:allow-start/end blocks, unless DetectFiles already
+	// found them to continue a block from the previous chunk
+	if fragment.allowBlocks == nil && !d.IgnoreGitleaksAllow && strings.Contains(fragment.Raw, gitleaksAllowStartSignature) {
+		fragment.allowBlocks, _ = findAllowBlocks(fragment.Raw, nil)
+	}
+
 	// build keyword map for prefiltering rules
 	normalizedRaw := strings.ToLower(fragment.Raw)
-	matches := d.prefilter.MatchString(normalizedRaw)
+	matches := prefilter.MatchString(normalizedRaw)
 	for _, m := range matches {
 		fragment.keywords[normalizedRaw[m.Pos():int(m.Pos())+len(m.Match())]] = true
 	}
-
-	for _, rule := range d.Config.Rules {
+	if d.Stats != nil {
+		d.Stats.addFragment(len(fragment.Raw), fragment.keywords)
+	}
+	for _, rule := range cfg.Rules {
+		explain := fragment.explain.forRule(rule.RuleID)
 		if len(rule.Keywords) == 0 {
 			// if not keywords are associated with the rule always scan the
 			// fragment using the rule
-			findings = append(findings, d.detectRule(fragment, rule)...)
+			explain.check(true, "keywords", func() string { return "the rule has no keywords and scans every fragment" })
+			ruleFindings, ruleSuppressions := d.detectRule(fragment, rule)
+			findings = append(findings, ruleFindings...)
+			suppressions = append(suppressions, ruleSuppressions...)
 			continue
 		}
 		fragmentContainsKeyword := false
 		// check if keywords are in the fragment
 		for _, k := range rule.Keywords {
 			if _, ok := fragment.keywords[strings.ToLower(k)]; ok {
+				if !fragmentContainsKeyword {
+					explain.check(true, "keywords", func() string { return fmt.Sprintf("found keyword %q", k) })
+				}
 				fragmentContainsKeyword = true
 			}
 		}
 		if fragmentContainsKeyword {
-			findings = append(findings, d.detectRule(fragment, rule)...)
+			ruleFindings, ruleSuppressions := d.detectRule(fragment, rule)
+			findings = append(findings, ruleFindings...)
+			suppressions = append(suppressions, ruleSuppressions...)
+		} else {
+			explain.check(false, "keywords", func() string { return fmt.Sprintf("none of the keywords %q is in the fragment", rule.Keywords) })
+		}
+	}
+
+	if d.Redact > 0 {
+		for i := range suppressions {
+			suppressions[i].Redact(d.Redact)
 		}
 	}
-	return filter(findings, d.Redact)
+	fragment.explain.filter(findings)
+	return filter(findings), suppressions
 }
 
-// detectRule scans the given fragment for the given rule and returns a list of findings
-func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Finding {
-	var findings []report.Finding
+// detectRule scans the given fragment for the given rule and returns a list
+// of findings and a list of findings suppressed by gitleaks:allow directives
+func (d *Detector) detectRule(fragment Fragment, rule config.Rule) ([]report.Finding, []report.Suppression) {
+	var (
+		findings     []report.Finding
+		suppressions []report.Suppression
+	)
+
+	explain := fragment.explain.forRule(rule.RuleID)
 
 	// check if filepath or commit is allowed for this rule
-	if rule.Allowlist.CommitAllowed(fragment.CommitSHA) ||
-		rule.Allowlist.PathAllowed(fragment.FilePath) {
-		return findings
+	if a := fileAllowlist(rule.Allowlists, fragment); a != nil {
+		explain.check(false, "rule allowlists", func() string { return fmt.Sprintf("%s ignores the path or commit", allowlistName(a)) })
+		d.addFileSuppression(fragment, rule.RuleID, a)
+		return findings, suppressions
 	}
+	explain.check(true, "rule allowlists", func() string { return "no allowlist ignores the path or commit" })
 
 	if rule.Path != nil && rule.Regex == nil {
 		// Path _only_ rule
 		if rule.Path.MatchString(fragment.FilePath) {
+			explain.check(true, "path", func() string { return fmt.Sprintf("%q matches %q", fragment.FilePath, rule.Path) })
 			finding := report.Finding{
 				Description: rule.Description,
 				File:        fragment.FilePath,
@@ -230,33 +343,48 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 				RuleID:      rule.RuleID,
 				Match:       fmt.Sprintf("file detected: %s", fragment.FilePath),
 				Tags:        rule.Tags,
+				Severity:    rule.Severity,
 			}
-			return append(findings, finding)
+			explain.match(finding).report()
+			return append(findings, finding), suppressions
 		}
+		explain.check(false, "path", func() string { return fmt.Sprintf("%q does not match %q", fragment.FilePath, rule.Path) })
 	} else if rule.Path != nil {
 		// if path is set _and_ a regex is set, then we need to check both
 		// so if the path does not match, then we should return early and not
 		// consider the regex
 		if !rule.Path.MatchString(fragment.FilePath) {
-			return findings
+			explain.check(false, "path", func() string { return fmt.Sprintf("%q does not match %q", fragment.FilePath, rule.Path) })
+			return findings, suppressions
 		}
+		explain.check(true, "path", func() string { return fmt.Sprintf("%q matches %q", fragment.FilePath, rule.Path) })
 	}
 
 	// if path only rule, skip content checks
 	if rule.Regex == nil {
-		return findings
+		return findings, suppressions
 	}
 
-	// If flag configure and raw data size bigger then the flag
+	// If flag configure and raw data size bigger then the flag. Files and
+	// git patches are checked as a whole by skipLarge, this catches
+	// fragments scanned directly.
 	if d.MaxTargetMegaBytes > 0 {
 		rawLength := len(fragment.Raw) / 1000000
 		if rawLength > d.MaxTargetMegaBytes {
 			log.Debug().Msgf("skipping file: %s scan due to size: %d", fragment.FilePath, rawLength)
-			return findings
+			explain.check(false, "size", func() string { return fmt.Sprintf("%d MB is larger than --max-target-megabytes", rawLength) })
+			return findings, suppressions
 		}
 	}
 
+	var start time.Time
+	if d.Stats != nil {
+		start = time.Now()
+	}
 	matchIndices := rule.Regex.FindAllStringIndex(fragment.Raw, -1)
+	if d.Stats != nil {
+		d.Stats.addRuleRun(rule.RuleID, time.Since(start))
+	}
 	for _, matchIndex := range matchIndices {
 		// extract secret from match
 		secret := strings.Trim(fragment.Raw[matchIndex[0]:matchIndex[1]], "\n")
@@ -283,14 +411,10 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 			Secret:      secret,
 			Match:       secret,
 			Tags:        rule.Tags,
+			Severity:    rule.Severity,
 			Line:        fragment.Raw[loc.startLineIndex:loc.endLineIndex],
 		}
 
-		if strings.Contains(fragment.Raw[loc.startLineIndex:loc.endLineIndex],
-			gitleaksAllowSignature) && !d.IgnoreGitleaksAllow {
-			continue
-		}
-
 		// by default if secret group is not set, we will check to see if there
 		// are any capture groups. If there are, we will use the first capture to start
 		groups := rule.Regex.FindStringSubmatch(secret)
@@ -305,36 +429,25 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 		} else {
 			if len(groups) <= rule.SecretGroup || len(groups) == 0 {
 				// Config validation should prevent this
+				explain.match(finding).check(false, "secret group", func() string { return fmt.Sprintf("the match has no group %d", rule.SecretGroup) })
 				continue
 			}
 			secret = groups[rule.SecretGroup]
 			finding.Secret = secret
 		}
-
-		// check if the regexTarget is defined in the allowlist "regexes" entry
-		allowlistTarget := finding.Secret
-		switch rule.Allowlist.RegexTarget {
-		case "match":
-			allowlistTarget = finding.Match
-		case "line":
-			allowlistTarget = finding.Line
-		}
-
-		globalAllowlistTarget := finding.Secret
-		switch d.Config.Allowlist.RegexTarget {
-		case "match":
-			globalAllowlistTarget = finding.Match
-		case "line":
-			globalAllowlistTarget = finding.Line
-		}
-		if rule.Allowlist.RegexAllowed(allowlistTarget) ||
-			d.Config.Allowlist.RegexAllowed(globalAllowlistTarget) {
-			continue
-		}
-
-		// check if the secret is in the list of stopwords
-		if rule.Allowlist.ContainsStopWord(finding.Secret) ||
-			d.Config.Allowlist.ContainsStopWord(finding.Secret) {
+		m := explain.match(finding)
+
+		// check if an allowlist of the rule or the config ignores the
+		// finding, allowlists with a description are reported
+		m.allowlists(fragment, rule, finding)
+		if a := findingAllowlist(fragment, rule, finding); a != nil {
+			if a.Description != "" {
+				suppressions = append(suppressions, report.Suppression{
+					Finding:   finding,
+					Directive: allowlistDirective,
+					Reason:    a.Description,
+				})
+			}
 			continue
 		}
 
@@ -344,8 +457,10 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 		if rule.Entropy != 0.0 {
 			if entropy <= rule.Entropy {
 				// entropy is too low, skip this finding
+				m.check(false, "entropy", func() string { return fmt.Sprintf("%.2f is not above the threshold %.2f", entropy, rule.Entropy) })
 				continue
 			}
+			m.check(true, "entropy", func() string { return fmt.Sprintf("%.2f is above the threshold %.2f", entropy, rule.Entropy) })
 			// NOTE: this is a goofy hack to get around the fact there golang's regex engine
 			// does not support positive lookaheads. Ideally we would want to add a
 			// restriction on generic rules regex that requires the secret match group
@@ -355,17 +470,91 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 			// TODO: this should be replaced with stop words
 			if strings.HasPrefix(rule.RuleID, "generic") {
 				if !containsDigit(secret) {
+					m.check(false, "generic digit", func() string { return "secrets of generic rules must contain a digit" })
 					continue
 				}
+				m.check(true, "generic digit", func() string { return "the secret contains a digit" })
+			}
+		} else {
+			m.check(true, "entropy", func() string { return fmt.Sprintf("%.2f, the rule has no threshold", entropy) })
+		}
+
+		// check the structure of the secret, e.g. an embedded checksum
+		if rule.Validator != "" && !validate(rule.Validator, finding.Secret) {
+			if !rule.KeepInvalid {
+				m.check(false, "validator", func() string { return fmt.Sprintf("the secret is not a valid %s", rule.Validator) })
+				continue
 			}
+			m.check(true, "validator", func() string {
+				return fmt.Sprintf("the secret is not a valid %s, kept with the %s tag", rule.Validator, invalidStructureTag)
+			})
+			finding.Tags = append(append([]string{}, finding.Tags...), invalidStructureTag)
+		} else if rule.Validator != "" {
+			m.check(true, "validator", func() string { return fmt.Sprintf("the secret is a valid %s", rule.Validator) })
 		}
 
+		// check if the finding is suppressed by a gitleaks:allow directive
+		// on its line or a gitleaks:allow-start/end block around it
+		if !d.IgnoreGitleaksAllow {
+			directive, ok := lineAllowDirective(finding.Line, rule.RuleID)
+			if !ok {
+				directive, ok = blockAllowDirective(fragment.allowBlocks, matchIndex[0], rule.RuleID)
+			}
+			if ok {
+				m.check(false, "gitleaks:allow", func() string { return fmt.Sprintf("suppressed by %s", directive.signature) })
+				suppressions = append(suppressions, report.Suppression{
+					Finding:   finding,
+					Directive: directive.signature,
+					Reason:    directive.reason,
+				})
+				continue
+			}
+			m.check(true, "gitleaks:allow", func() string { return "no gitleaks:allow comment applies" })
+		} else {
+			m.check(true, "gitleaks:allow", func() string { return "gitleaks:allow comments are ignored" })
+		}
+
+		m.report()
 		findings = append(findings, finding)
 	}
-	return findings
+	return findings, suppressions
+}
+
+// ExcludePath prevents a file that is written while scanning, like a
+// streamed report, from being scanned.
+func (d *Detector) ExcludePath(path string) error {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	d.excludedPaths[absolutePath] = true
+	return nil
+}
+
+func (d *Detector) excluded(path string) bool {
+	if len(d.excludedPaths) == 0 {
+		return false
+	}
+	absolutePath, err := filepath.Abs(path)
+	return err == nil && d.excludedPaths[absolutePath]
 }
 
-// addFinding synchronously adds a finding to the findings slice
+// skipLarge returns true if a file of size bytes is larger than
+// MaxTargetMegaBytes and counts the skipped file.
+func (d *Detector) skipLarge(path string, size int64) bool {
+	if d.MaxTargetMegaBytes <= 0 || size/1000000 <= int64(d.MaxTargetMegaBytes) {
+		return false
+	}
+	log.Debug().Msgf("skipping file: %s scan due to size: %d", path, size/1000000)
+	metrics.SkippedFiles.Inc(metrics.SkipReasonMaxTargetMegabytes)
+	return true
+}
+
+// addFinding synchronously adds a finding to the findings slice. Findings
+// that are not ignored are redacted and published right away, unless a
+// Verifier is set: their secrets are then verified after the scan, see
+// scanFindings, so ignored and baselined secrets are never sent to a
+// verify endpoint.
 func (d *Detector) addFinding(finding report.Finding) {
 	globalFingerprint := fmt.Sprintf("%s:%s:%d", finding.File, finding.RuleID, finding.StartLine)
 	if finding.Commit != "" {
@@ -375,33 +564,154 @@ func (d *Detector) addFinding(finding report.Finding) {
 	}
 
 	// check if we should ignore this finding
-	if _, ok := d.gitleaksIgnore[globalFingerprint]; ok {
-		log.Debug().Msgf("ignoring finding with global Fingerprint %s",
-			finding.Fingerprint)
+	if entry, ok := d.ignored(finding, globalFingerprint); ok {
+		log.Debug().Msgf("ignoring finding with Fingerprint %s (owner: %s, reason: %s)",
+			finding.Fingerprint, entry.owner, entry.reason)
 		return
-	} else if finding.Commit != "" {
-		// Awkward nested if because I'm not sure how to chain these two conditions.
-		if _, ok := d.gitleaksIgnore[finding.Fingerprint]; ok {
-			log.Debug().Msgf("ignoring finding with Fingerprint %s",
-				finding.Fingerprint)
-			return
-		}
 	}
 
-	if d.baseline != nil && !IsNew(finding, d.baseline) {
+	if d.Baseline != nil && d.Baseline.Contains(d.baselineFinding(finding), d.BaselineIgnoreLocation) {
 		log.Debug().Msgf("baseline duplicate -- ignoring finding with Fingerprint %s", finding.Fingerprint)
 		return
 	}
 
 	d.findingMutex.Lock()
+	defer d.findingMutex.Unlock()
+	if d.Verifier == nil {
+		d.publish(&finding)
+		d.published++
+	}
 	d.findings = append(d.findings, finding)
+}
+
+// baselineFinding returns the finding as it is written to reports, so it
+// can be compared with the baseline, which may have been written with
+// redacted secrets.
+func (d *Detector) baselineFinding(finding report.Finding) report.Finding {
+	if d.Redact > 0 {
+		finding.HashSecret()
+		finding.Redact(d.Redact)
+	}
+	return finding
+}
+
+// publish redacts a finding that was added to the findings slice and
+// reports it to the verbose output, OnFinding and the statistics. The
+// caller must hold findingMutex.
+func (d *Detector) publish(finding *report.Finding) {
+	if d.HashSecrets {
+		finding.HashSecret()
+	}
+	if d.Redact > 0 {
+		finding.Redact(d.Redact)
+	}
 	if d.Verbose {
-		printFinding(finding, d.NoColor)
+		printFinding(*finding, d.NoColor)
+	}
+	if d.OnFinding != nil {
+		d.OnFinding(*finding)
+	}
+	if d.Stats != nil {
+		d.Stats.addFinding(finding.RuleID)
+	}
+	metrics.Findings.Inc(finding.RuleID, finding.Severity)
+}
+
+// scanFindings returns the findings of the scan once they are verified,
+// redacted and published.
+func (d *Detector) scanFindings() []report.Finding {
+	d.findingMutex.Lock()
+	defer d.findingMutex.Unlock()
+	pending := d.findings[d.published:]
+	d.verifyFindings(pending)
+	for i := range pending {
+		d.publish(&pending[i])
 	}
+	d.published = len(d.findings)
+	return d.findings
+}
+
+// addSuppression synchronously adds a suppression to the suppressions slice
+func (d *Detector) addSuppression(suppression report.Suppression) {
+	d.findingMutex.Lock()
+	d.suppressions = append(d.suppressions, suppression)
 	d.findingMutex.Unlock()
 }
 
+// addFileSuppression records that the fragment's file was not scanned by
+// the rule with the given id, or by any rule if ruleID is empty, because
+// of an allowlist with a description. The suppression has no secret and
+// no location since the file is skipped as a whole, and is only recorded
+// once per file, commit, rule and allowlist.
+func (d *Detector) addFileSuppression(fragment Fragment, ruleID string, a *config.Allowlist) {
+	if a.Description == "" || fragment.explain != nil {
+		return
+	}
+	key := strings.Join([]string{fragment.CommitSHA, fragment.FilePath, ruleID, a.Description}, "\x00")
+	d.findingMutex.Lock()
+	defer d.findingMutex.Unlock()
+	if d.allowedFiles[key] {
+		return
+	}
+	if d.allowedFiles == nil {
+		d.allowedFiles = make(map[string]bool)
+	}
+	d.allowedFiles[key] = true
+	d.suppressions = append(d.suppressions, report.Suppression{
+		Finding: report.Finding{
+			RuleID:      ruleID,
+			File:        fragment.FilePath,
+			SymlinkFile: fragment.SymlinkFile,
+			Commit:      fragment.CommitSHA,
+			Tags:        []string{},
+		},
+		Directive: allowlistDirective,
+		Reason:    a.Description,
+	})
+}
+
+// Suppressions returns the findings that were suppressed by gitleaks:allow
+// directives during the scan.
+func (d *Detector) Suppressions() []report.Suppression {
+	d.findingMutex.Lock()
+	defer d.findingMutex.Unlock()
+	return append([]report.Suppression{}, d.suppressions...)
+}
+
 // addCommit synchronously adds a commit to the commit slice
 func (d *Detector) addCommit(commit string) {
+	d.commitMutex.Lock()
 	d.commitMap[commit] = true
+	d.commitMutex.Unlock()
+}
+
+// commits returns the number of commits that have been scanned.
+func (d *Detector) commits() int {
+	d.commitMutex.Lock()
+	defer d.commitMutex.Unlock()
+	return len(d.commitMap)
+}
+
+// fileAllowlist returns the first of the allowlists that ignores every
+// finding in the fragment, or nil.
+func fileAllowlist(allowlists []config.Allowlist, fragment Fragment) *config.Allowlist {
+	for i := range allowlists {
+		if allowlists[i].FileAllowed(fragment.CommitSHA, fragment.FilePath) {
+			return &allowlists[i]
+		}
+	}
+	return nil
+}
+
+// findingAllowlist returns the first allowlist of the rule or of the
+// fragment's config that ignores the finding, or nil.
+func findingAllowlist(fragment Fragment, rule config.Rule, finding report.Finding) *config.Allowlist {
+	for _, allowlists := range [][]config.Allowlist{rule.Allowlists, fragment.config.Allowlists} {
+		for i := range allowlists {
+			if allowlists[i].FindingAllowed(fragment.CommitSHA, fragment.FilePath, finding.Secret, finding.Match, finding.Line) {
+				return &allowlists[i]
+			}
+		}
+	}
+	return nil
 }
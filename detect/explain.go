@@ -0,0 +1,261 @@
+package detect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Explanation is the decision path of a rule on a fragment, see
+// Detector.Explain.
+type Explanation struct {
+	RuleID string
+
+	// Checks are the checks of the fragment as a whole, made before the
+	// rule's regex runs.
+	Checks []ExplainCheck
+
+	// Matches are the regex matches of the rule, in the order they were
+	// found.
+	Matches []*ExplainMatch
+}
+
+// ExplainCheck is a single check of the decision path. A check that did
+// not pass ends the decision path.
+type ExplainCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+func (c ExplainCheck) String() string {
+	result := "pass"
+	if !c.Passed {
+		result = "skip"
+	}
+	return fmt.Sprintf("%s  %s: %s", result, c.Name, c.Detail)
+}
+
+// ExplainMatch is a regex match of the rule and the checks it went through.
+type ExplainMatch struct {
+	// Finding is the finding the match would be reported as. Its secret
+	// is not redacted and its lines start at 0, like the findings of
+	// Detect.
+	Finding report.Finding
+
+	Checks []ExplainCheck
+
+	// Reported is true if the match was reported as a finding.
+	Reported bool
+}
+
+// Explain scans the fragment with every rule like Detect does and returns
+// the decision path of the rule with the given id: the checks that decided
+// whether the fragment was scanned by the rule and, for each regex match,
+// whether it was reported and why not. Findings are not added to the
+// detector and are not matched against .gitleaksignore entries or the
+// baseline.
+func (d *Detector) Explain(fragment Fragment, ruleID string) (*Explanation, error) {
+	cfg, _ := d.scanConfig(fragment.FilePath)
+	if _, ok := cfg.Rules[ruleID]; !ok {
+		return nil, fmt.Errorf("rule %s not found", ruleID)
+	}
+
+	e := &Explanation{RuleID: ruleID}
+	fragment.explain = e
+	d.detect(fragment)
+	return e, nil
+}
+
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule %s\n", e.RuleID)
+	for _, c := range e.Checks {
+		fmt.Fprintf(&b, "  %s\n", c)
+	}
+	for i, m := range e.Matches {
+		f := m.Finding
+		fmt.Fprintf(&b, "match %d at line %d, column %d: %q\n", i+1, f.StartLine+1, f.StartColumn, f.Match)
+		fmt.Fprintf(&b, "  secret: %q\n", f.Secret)
+		for _, c := range m.Checks {
+			fmt.Fprintf(&b, "  %s\n", c)
+		}
+		if m.Reported {
+			b.WriteString("  => reported\n")
+		} else {
+			b.WriteString("  => not reported\n")
+		}
+	}
+	if len(e.Matches) == 0 {
+		b.WriteString("no matches\n")
+	}
+	return b.String()
+}
+
+// forRule returns the explanation if it is for the rule, or nil. All
+// methods of Explanation and ExplainMatch do nothing on nil so detection
+// does not have to check whether it is explained.
+func (e *Explanation) forRule(ruleID string) *Explanation {
+	if e == nil || e.RuleID != ruleID {
+		return nil
+	}
+	return e
+}
+
+// check adds a check of the rule. detail is only called if the rule is
+// explained, so scans do not pay for formatting details nobody reads.
+func (e *Explanation) check(passed bool, name string, detail func() string) {
+	if e == nil {
+		return
+	}
+	e.Checks = append(e.Checks, ExplainCheck{Name: name, Passed: passed, Detail: detail()})
+}
+
+// match adds a regex match of the rule.
+func (e *Explanation) match(finding report.Finding) *ExplainMatch {
+	if e == nil {
+		return nil
+	}
+	m := &ExplainMatch{Finding: finding}
+	e.Matches = append(e.Matches, m)
+	return m
+}
+
+// filter explains the deduplication of the findings of all rules in
+// filter.
+func (e *Explanation) filter(findings []report.Finding) {
+	if e == nil {
+		return
+	}
+	for _, m := range e.Matches {
+		if !m.Reported {
+			continue
+		}
+		if !isGeneric(m.Finding) {
+			m.check(true, "dedup", func() string { return "only findings of generic rules are deduplicated" })
+		} else if p := precedingFinding(m.Finding, findings); p != nil {
+			m.Reported = false
+			m.check(false, "dedup", func() string {
+				return fmt.Sprintf("rule %s found %q on the same line and takes precedence", p.RuleID, p.Secret)
+			})
+		} else {
+			m.check(true, "dedup", func() string { return "no other rule found the secret on the same line" })
+		}
+	}
+}
+
+// check adds a check of the match, see Explanation.check.
+func (m *ExplainMatch) check(passed bool, name string, detail func() string) {
+	if m == nil {
+		return
+	}
+	m.Checks = append(m.Checks, ExplainCheck{Name: name, Passed: passed, Detail: detail()})
+}
+
+// report marks the match as reported, unless filter removes it.
+func (m *ExplainMatch) report() {
+	if m != nil {
+		m.Reported = true
+	}
+}
+
+// allowlists explains the allowlists of the rule and of the fragment's
+// config in the order findingAllowlist checks them.
+func (m *ExplainMatch) allowlists(fragment Fragment, rule config.Rule, finding report.Finding) {
+	if m == nil {
+		return
+	}
+	count := 0
+	for _, scope := range []struct {
+		name       string
+		allowlists []config.Allowlist
+	}{{"rule", rule.Allowlists}, {"config", fragment.config.Allowlists}} {
+		for i := range scope.allowlists {
+			a := &scope.allowlists[i]
+			count++
+			allowed := a.FindingAllowed(fragment.CommitSHA, fragment.FilePath, finding.Secret, finding.Match, finding.Line)
+			name := fmt.Sprintf("%s allowlist %d", scope.name, i+1)
+			if a.Description != "" {
+				name += " " + strconv.Quote(a.Description)
+			}
+			m.check(!allowed, name, func() string { return allowlistDetail(a, fragment, finding) })
+			if allowed {
+				return
+			}
+		}
+	}
+	if count == 0 {
+		m.check(true, "allowlists", func() string { return "neither the rule nor the config has allowlists" })
+	}
+}
+
+// allowlistDetail describes how each criterion of the allowlist decided
+// about the finding.
+func allowlistDetail(a *config.Allowlist, fragment Fragment, finding report.Finding) string {
+	var details []string
+	if len(a.Commits) != 0 {
+		if a.CommitAllowed(fragment.CommitSHA) {
+			details = append(details, "commit is allowed")
+		} else {
+			details = append(details, "commit is not allowed")
+		}
+	}
+	if len(a.Paths) != 0 {
+		if fragment.FilePath != "" && a.PathAllowed(fragment.FilePath) {
+			details = append(details, "path is allowed")
+		} else {
+			details = append(details, "path is not allowed")
+		}
+	}
+	if len(a.Regexes) != 0 {
+		targetName, target := "secret", finding.Secret
+		switch a.RegexTarget {
+		case "match":
+			targetName, target = "match", finding.Match
+		case "line":
+			targetName, target = "line", finding.Line
+		}
+		matched := ""
+		for _, re := range a.Regexes {
+			if re.MatchString(target) {
+				matched = re.String()
+				break
+			}
+		}
+		if matched != "" {
+			details = append(details, fmt.Sprintf("regex %q matches the %s", matched, targetName))
+		} else {
+			details = append(details, fmt.Sprintf("no regex matches the %s", targetName))
+		}
+	}
+	if len(a.StopWords) != 0 {
+		stopWord := ""
+		secret := strings.ToLower(finding.Secret)
+		for _, w := range a.StopWords {
+			if strings.Contains(secret, strings.ToLower(w)) {
+				stopWord = w
+				break
+			}
+		}
+		if stopWord != "" {
+			details = append(details, fmt.Sprintf("the secret contains stopword %q", stopWord))
+		} else {
+			details = append(details, "the secret contains no stopword")
+		}
+	}
+	if len(details) == 0 {
+		return "empty allowlist"
+	}
+	return a.MatchCondition.String() + " of: " + strings.Join(details, ", ")
+}
+
+// allowlistName names an allowlist in explanations and logs.
+func allowlistName(a *config.Allowlist) string {
+	if a.Description != "" {
+		return "allowlist " + strconv.Quote(a.Description)
+	}
+	return "an allowlist without description"
+}
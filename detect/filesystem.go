@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/h2non/filetype"
+	"github.com/zricethezav/gitleaks/v8/metrics"
 	"github.com/zricethezav/gitleaks/v8/report"
 	"github.com/zricethezav/gitleaks/v8/sources"
 )
@@ -19,10 +20,16 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 				return err
 			}
 			defer f.Close()
+			if info, err := f.Stat(); err == nil && d.skipLarge(p.Path, info.Size()) {
+				return nil
+			}
 
 			// Buffer to hold file chunks
 			buf := make([]byte, chunkSize)
 			totalLines := 0
+			// allowOpen is the directive of a gitleaks:allow-start block
+			// that is still open at the end of the previous chunk
+			var allowOpen *allowDirective
 			for {
 				n, err := f.Read(buf)
 				if err != nil && err != io.EOF {
@@ -38,6 +45,7 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 					return err
 				}
 				if mimetype.MIME.Type == "application" {
+					metrics.SkippedFiles.Inc(metrics.SkipReasonBinary)
 					return nil // skip binary files
 				}
 
@@ -51,12 +59,21 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 				if p.Symlink != "" {
 					fragment.SymlinkFile = p.Symlink
 				}
-				for _, finding := range d.Detect(fragment) {
+				if !d.IgnoreGitleaksAllow && (allowOpen != nil || strings.Contains(fragment.Raw, gitleaksAllowStartSignature)) {
+					fragment.allowBlocks, allowOpen = findAllowBlocks(fragment.Raw, allowOpen)
+				}
+				findings, suppressions := d.detect(fragment)
+				for _, finding := range findings {
 					// need to add 1 since line counting starts at 1
 					finding.StartLine += (totalLines - linesInChunk) + 1
 					finding.EndLine += (totalLines - linesInChunk) + 1
 					d.addFinding(finding)
 				}
+				for _, s := range suppressions {
+					s.StartLine += (totalLines - linesInChunk) + 1
+					s.EndLine += (totalLines - linesInChunk) + 1
+					d.addSuppression(s)
+				}
 			}
 
 			return nil
@@ -64,8 +81,8 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 	}
 
 	if err := d.Sema.Wait(); err != nil {
-		return d.findings, err
+		return d.scanFindings(), err
 	}
 
-	return d.findings, nil
+	return d.scanFindings(), nil
 }
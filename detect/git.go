@@ -3,6 +3,7 @@ package detect
 import (
 	"github.com/gitleaks/go-gitdiff/gitdiff"
 	"github.com/rs/zerolog/log"
+	"github.com/zricethezav/gitleaks/v8/metrics"
 	"github.com/zricethezav/gitleaks/v8/report"
 	"github.com/zricethezav/gitleaks/v8/sources"
 )
@@ -22,7 +23,11 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 			}
 
 			// skip binary files
-			if gitdiffFile.IsBinary || gitdiffFile.IsDelete {
+			if gitdiffFile.IsBinary {
+				metrics.SkippedFiles.Inc(metrics.SkipReasonBinary)
+				continue
+			}
+			if gitdiffFile.IsDelete {
 				continue
 			}
 
@@ -30,13 +35,17 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 			commitSHA := ""
 			if gitdiffFile.PatchHeader != nil {
 				commitSHA = gitdiffFile.PatchHeader.SHA
-				if d.Config.Allowlist.CommitAllowed(gitdiffFile.PatchHeader.SHA) {
+				if a := fileAllowlist(d.Config.Allowlists, Fragment{CommitSHA: commitSHA}); a != nil {
+					d.addFileSuppression(Fragment{CommitSHA: commitSHA, FilePath: gitdiffFile.NewName}, "", a)
 					continue
 				}
 			}
 			d.addCommit(commitSHA)
 
 			d.Sema.Go(func() error {
+				if d.skipLarge(gitdiffFile.NewName, addedBytes(gitdiffFile)) {
+					return nil
+				}
 				for _, textFragment := range gitdiffFile.TextFragments {
 					if textFragment == nil {
 						return nil
@@ -48,9 +57,14 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 						FilePath:  gitdiffFile.NewName,
 					}
 
-					for _, finding := range d.Detect(fragment) {
+					findings, suppressions := d.detect(fragment)
+					for _, finding := range findings {
 						d.addFinding(augmentGitFinding(finding, textFragment, gitdiffFile))
 					}
+					for _, s := range suppressions {
+						s.Finding = augmentGitFinding(s.Finding, textFragment, gitdiffFile)
+						d.addSuppression(s)
+					}
 				}
 				return nil
 			})
@@ -60,14 +74,31 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 				break
 			}
 
-			return d.findings, err
+			return d.scanFindings(), err
 		}
 	}
 
 	if err := d.Sema.Wait(); err != nil {
-		return d.findings, err
+		return d.scanFindings(), err
 	}
-	log.Info().Msgf("%d commits scanned.", len(d.commitMap))
+	log.Info().Msgf("%d commits scanned.", d.commits())
 	log.Debug().Msg("Note: this number might be smaller than expected due to commits with no additions")
-	return d.findings, nil
+	return d.scanFindings(), nil
+}
+
+// addedBytes returns the size of the lines a patch adds to a file, which is
+// what a git scan reads of it.
+func addedBytes(f *gitdiff.File) int64 {
+	var size int64
+	for _, textFragment := range f.TextFragments {
+		if textFragment == nil {
+			continue
+		}
+		for _, line := range textFragment.Lines {
+			if line.Op == gitdiff.OpAdd {
+				size += int64(len(line.Line))
+			}
+		}
+	}
+	return size
 }
@@ -0,0 +1,291 @@
+package detect
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// ignoreDateLayout is the layout of the expires field in .gitleaksignore
+const ignoreDateLayout = "2006-01-02"
+
+// ignoreFields are the fields of an extended .gitleaksignore entry
+var ignoreFields = map[string]bool{
+	"fingerprint": true,
+	"secret":      true,
+	"rule":        true,
+	"path":        true,
+	"expires":     true,
+	"owner":       true,
+	"reason":      true,
+}
+
+// ignoreEntry is an entry of a .gitleaksignore file. An entry is either a
+// plain fingerprint line:
+//
+//	cd5226711335c68be1e720b318b7bc3135a30eb2:main.go:aws-access-token:23
+//
+// or a list of key=value fields, where values may be double quoted:
+//
+//	secret=sha256:9f86d08... rule=aws-access-token path="testdata/**" expires=2024-06-30 owner=alice reason="rotated"
+//	fingerprint=main.go:aws-access-token:23 owner=alice reason="false positive"
+//
+// Extended entries match by fingerprint, or by the SHA-256 of the secret
+// optionally narrowed down by rule ID and path glob. Entries with an
+// expiry date stop matching after that date.
+type ignoreEntry struct {
+	// dir is the directory of a nested .gitleaksignore. Its entries only
+	// match findings beneath dir and its path globs are relative to dir.
+	dir string
+
+	fingerprint string
+	secretHash  string
+	ruleID      string
+	path        *regexp.Regexp
+	expires     time.Time
+	owner       string
+	reason      string
+}
+
+// expired returns true if the entry has an expiry date that has passed.
+// Entries are valid until the end of their expiry date in UTC.
+func (e ignoreEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires.AddDate(0, 0, 1))
+}
+
+// matchesSecret returns true if a secret entry applies to the finding.
+func (e ignoreEntry) matchesSecret(finding report.Finding) bool {
+	if e.ruleID != "" && e.ruleID != finding.RuleID {
+		return false
+	}
+	if !e.withinDir(finding) {
+		return false
+	}
+	if e.path != nil && !e.path.MatchString(strings.TrimPrefix(filepath.ToSlash(finding.File), e.dir+"/")) {
+		return false
+	}
+	return true
+}
+
+// withinDir returns true if the finding is beneath the directory of the
+// entry's .gitleaksignore file.
+func (e ignoreEntry) withinDir(finding report.Finding) bool {
+	return within(e.dir, filepath.ToSlash(finding.File))
+}
+
+// AddGitleaksIgnore loads the entries of a .gitleaksignore file.
+func (d *Detector) AddGitleaksIgnore(gitleaksIgnorePath string) error {
+	return d.addGitleaksIgnore(gitleaksIgnorePath, "")
+}
+
+// addGitleaksIgnore loads the entries of a .gitleaksignore file that
+// applies to the findings beneath dir.
+func (d *Detector) addGitleaksIgnore(gitleaksIgnorePath string, dir string) error {
+	log.Debug().Msgf("found .gitleaksignore file: %s", gitleaksIgnorePath)
+	file, err := os.Open(gitleaksIgnorePath)
+
+	if err != nil {
+		return err
+	}
+
+	// https://github.com/securego/gosec/issues/512
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warn().Msgf("Error closing .gitleaksignore file: %s\n", err)
+		}
+	}()
+	scanner := bufio.NewScanner(file)
+
+	now := time.Now()
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, err := parseIgnoreEntry(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", gitleaksIgnorePath, lineNum, err)
+		}
+		entry.dir = dir
+		if entry.expired(now) {
+			log.Warn().Msgf("%s:%d: ignore entry expired on %s, matching findings will be reported",
+				gitleaksIgnorePath, lineNum, entry.expires.Format(ignoreDateLayout))
+		}
+		if entry.fingerprint != "" {
+			d.gitleaksIgnore[entry.fingerprint] = entry
+		} else {
+			d.gitleaksIgnoreSecrets[entry.secretHash] = append(d.gitleaksIgnoreSecrets[entry.secretHash], entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// ignored returns the ignore entry matching the finding, if any.
+func (d *Detector) ignored(finding report.Finding, globalFingerprint string) (ignoreEntry, bool) {
+	now := time.Now()
+	if entry, ok := d.gitleaksIgnore[globalFingerprint]; ok && !entry.expired(now) && entry.withinDir(finding) {
+		return entry, true
+	}
+	if finding.Commit != "" {
+		if entry, ok := d.gitleaksIgnore[finding.Fingerprint]; ok && !entry.expired(now) && entry.withinDir(finding) {
+			return entry, true
+		}
+	}
+	if len(d.gitleaksIgnoreSecrets) != 0 {
+		for _, entry := range d.gitleaksIgnoreSecrets[finding.SecretSHA256()] {
+			if entry.matchesSecret(finding) && !entry.expired(now) {
+				return entry, true
+			}
+		}
+	}
+	return ignoreEntry{}, false
+}
+
+// IgnoreFingerprint parses a single .gitleaksignore line and returns the
+// fingerprint it ignores, which is empty for entries that match by secret.
+func IgnoreFingerprint(line string) (string, error) {
+	entry, err := parseIgnoreEntry(line)
+	return entry.fingerprint, err
+}
+
+// parseIgnoreEntry parses a single .gitleaksignore line.
+func parseIgnoreEntry(line string) (ignoreEntry, error) {
+	fields, err := parseIgnoreFields(line)
+	if err != nil {
+		return ignoreEntry{}, err
+	}
+	if fields == nil {
+		// plain fingerprint line
+		return ignoreEntry{fingerprint: line}, nil
+	}
+
+	var entry ignoreEntry
+	for key, value := range fields {
+		switch key {
+		case "fingerprint":
+			entry.fingerprint = value
+		case "secret":
+			entry.secretHash = strings.ToLower(strings.TrimPrefix(value, "sha256:"))
+		case "rule":
+			entry.ruleID = value
+		case "path":
+			if entry.path, err = globToRegexp(value); err != nil {
+				return ignoreEntry{}, fmt.Errorf("invalid path %q: %w", value, err)
+			}
+		case "expires":
+			if entry.expires, err = time.Parse(ignoreDateLayout, value); err != nil {
+				return ignoreEntry{}, fmt.Errorf("invalid expires date %q, expected YYYY-MM-DD", value)
+			}
+		case "owner":
+			entry.owner = value
+		case "reason":
+			entry.reason = value
+		}
+	}
+
+	switch {
+	case entry.fingerprint != "" && entry.secretHash != "":
+		return ignoreEntry{}, fmt.Errorf("fingerprint and secret can not be used together")
+	case entry.fingerprint == "" && entry.secretHash == "":
+		return ignoreEntry{}, fmt.Errorf("either fingerprint or secret must be set")
+	case entry.fingerprint != "" && (entry.ruleID != "" || entry.path != nil):
+		return ignoreEntry{}, fmt.Errorf("rule and path can only be used with secret")
+	case entry.secretHash != "" && !isSHA256(entry.secretHash):
+		return ignoreEntry{}, fmt.Errorf("secret must be a hex encoded sha256 hash")
+	}
+	return entry, nil
+}
+
+// parseIgnoreFields splits an extended entry into its key=value fields.
+// It returns nil if the line is a plain fingerprint.
+func parseIgnoreFields(line string) (map[string]string, error) {
+	eq := strings.IndexByte(line, '=')
+	if eq == -1 || !ignoreFields[line[:eq]] {
+		return nil, nil
+	}
+
+	fields := make(map[string]string)
+	for s := line; s != ""; s = strings.TrimLeft(s, " \t") {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \t") {
+			return nil, fmt.Errorf("expected key=value, got %q", s)
+		}
+		key := s[:eq]
+		if !ignoreFields[key] {
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quote in %s", key)
+			}
+			value = s[1 : end+1]
+			s = s[end+2:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end == -1 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("duplicate field %q", key)
+		}
+		fields[key] = value
+	}
+	return fields, nil
+}
+
+// globToRegexp converts a path glob to a regular expression. "*" and "?"
+// do not match path separators while "**" matches any number of
+// directories.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				sb.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+package detect
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// nestedConfig is a config that applies to the files beneath a directory
+// of the scanned source instead of the detector's config.
+type nestedConfig struct {
+	// dir is the slash separated directory the config applies to, in the
+	// same form as the paths of scanned files
+	dir string
+
+	// path is the path of the config file, which is not scanned
+	path string
+
+	config    config.Config
+	prefilter ahocorasick.Trie
+}
+
+// scanConfig returns the config and keyword prefilter that apply to a file.
+// Files beneath a directory with a nested config use the deepest one.
+func (d *Detector) scanConfig(filePath string) (*config.Config, *ahocorasick.Trie) {
+	if filePath != "" {
+		filePath = filepath.ToSlash(filePath)
+		for _, n := range d.nestedConfigs {
+			if within(n.dir, filePath) {
+				return &n.config, &n.prefilter
+			}
+		}
+	}
+	return &d.Config, &d.prefilter
+}
+
+// nestedConfigPath returns true if filePath is a nested config file.
+func (d *Detector) nestedConfigPath(filePath string) bool {
+	filePath = filepath.ToSlash(filePath)
+	for _, n := range d.nestedConfigs {
+		if n.path == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+// AddNestedConfig makes cfg apply to the files beneath dir. dir must be in
+// the same form as the paths of scanned files, which is relative to the
+// repository root for git scans and includes the source for --no-git scans.
+// cfg is used as is, see config.Config.Inherit to merge it with a parent.
+func (d *Detector) AddNestedConfig(dir string, cfg config.Config) {
+	cfg.UpgradeAllowlists()
+	n := &nestedConfig{
+		dir:       cleanDir(dir),
+		path:      filepath.ToSlash(cfg.Path),
+		config:    cfg,
+		prefilter: *ahocorasick.NewTrieBuilder().AddStrings(cfg.Keywords).Build(),
+	}
+	d.nestedConfigs = append(d.nestedConfigs, n)
+	// deepest directories first so scanConfig finds the closest config
+	sort.SliceStable(d.nestedConfigs, func(i, j int) bool {
+		return strings.Count(d.nestedConfigs[i].dir, "/") > strings.Count(d.nestedConfigs[j].dir, "/")
+	})
+}
+
+// LoadNestedConfigs finds config files and .gitleaksignore files in the
+// subdirectories of source, see config.FindFile. A nested config is merged
+// with the config of its parent directory and applies to the files beneath
+// its directory. A nested .gitleaksignore only ignores findings beneath its
+// directory.
+//
+// If relative is set, the directories are registered relative to source,
+// which matches the file paths of git scans, and only files tracked by git
+// are considered. Otherwise they include source like the file paths of
+// --no-git scans. Directories and files ignored by a path of the config's
+// allowlists are not searched. A nested file that can not be loaded is
+// skipped with a warning.
+func (d *Detector) LoadNestedConfigs(source string, relative bool) error {
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return err
+	}
+
+	scanPath := func(p string) (string, error) {
+		if relative {
+			rel, err := filepath.Rel(source, p)
+			return filepath.ToSlash(rel), err
+		}
+		return p, nil
+	}
+	scopeDir := func(p string) (string, error) {
+		return scanPath(filepath.Dir(p))
+	}
+	// allowed returns true if the scan does not read the file at p, or
+	// any file in it if p is a directory
+	allowed := func(p string, dir bool) bool {
+		sp, err := scanPath(p)
+		if err != nil {
+			return false
+		}
+		if dir {
+			sp += "/"
+		}
+		return fileAllowlist(d.Config.Allowlists, Fragment{FilePath: sp}) != nil
+	}
+
+	configPaths, ignorePaths, err := findNestedFiles(source, relative, allowed)
+	if err != nil {
+		return err
+	}
+
+	// parents first so every config can inherit from its parent
+	sort.SliceStable(configPaths, func(i, j int) bool {
+		return strings.Count(configPaths[i], string(filepath.Separator)) < strings.Count(configPaths[j], string(filepath.Separator))
+	})
+	for _, p := range configPaths {
+		dir, err := scopeDir(p)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.LoadFile(p)
+		if err != nil {
+			log.Warn().Err(err).Msgf("skipping nested config %s", p)
+			continue
+		}
+		parent, _ := d.scanConfig(path.Join(filepath.ToSlash(dir), filepath.Base(p)))
+		if cfg, err = cfg.Inherit(*parent); err != nil {
+			log.Warn().Err(err).Msgf("skipping nested config %s", p)
+			continue
+		}
+		cfg.Path = filepath.Join(dir, filepath.Base(p))
+		log.Debug().Msgf("using nested config %s for %s", p, dir)
+		d.AddNestedConfig(dir, cfg)
+	}
+	for _, p := range ignorePaths {
+		dir, err := scopeDir(p)
+		if err != nil {
+			return err
+		}
+		if err := d.addGitleaksIgnore(p, cleanDir(dir)); err != nil {
+			log.Warn().Err(err).Msgf("skipping nested .gitleaksignore %s", p)
+		}
+	}
+	return nil
+}
+
+// findNestedFiles returns the paths of the config files and .gitleaksignore
+// files in the subdirectories of source, leaving out the ones allowed
+// returns true for. If tracked is set, the files git tracks are searched,
+// falling back to the directory tree if source is not a git repository.
+func findNestedFiles(source string, tracked bool, allowed func(p string, dir bool) bool) (configPaths []string, ignorePaths []string, err error) {
+	var (
+		configDirs = make(map[string]bool)
+		root       = filepath.Clean(source)
+	)
+	add := func(p string) {
+		dir := filepath.Dir(p)
+		if dir == root {
+			// the root config and .gitleaksignore are loaded by the caller
+			return
+		}
+		if filepath.Base(p) == ".gitleaksignore" {
+			ignorePaths = append(ignorePaths, p)
+			return
+		}
+		for _, name := range config.FileNames {
+			if filepath.Base(p) == name && !configDirs[dir] {
+				configDirs[dir] = true
+				if cfgPath := config.FindFile(dir); cfgPath != "" {
+					configPaths = append(configPaths, cfgPath)
+				}
+			}
+		}
+	}
+
+	if tracked {
+		files, err := sources.GitFiles(source)
+		if err == nil {
+			for _, f := range files {
+				p := filepath.Join(root, f)
+				if !allowed(p, false) {
+					add(p)
+				}
+			}
+			return configPaths, ignorePaths, nil
+		}
+		log.Debug().Err(err).Msgf("could not list the files git tracks in %s, searching all files", source)
+	}
+
+	err = filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			log.Warn().Err(err).Msgf("skipping %s while searching nested configs", p)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" || (p != root && allowed(p, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !allowed(p, false) {
+			add(p)
+		}
+		return nil
+	})
+	return configPaths, ignorePaths, err
+}
+
+// FilterRules removes the rules for which keep returns false from the
+// detector's config and every nested config.
+func (d *Detector) FilterRules(keep func(config.Rule) bool) {
+	d.Config.FilterRules(keep)
+	for _, n := range d.nestedConfigs {
+		n.config.FilterRules(keep)
+	}
+}
+
+// cleanDir returns dir as a clean slash separated path, with "" for the
+// current directory.
+func cleanDir(dir string) string {
+	dir = path.Clean(filepath.ToSlash(dir))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// within returns true if the slash separated filePath is beneath dir.
+func within(dir string, filePath string) bool {
+	return dir == "" || strings.HasPrefix(path.Clean(filePath), dir+"/")
+}
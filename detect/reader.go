@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io"
 
+	"github.com/zricethezav/gitleaks/v8/metrics"
 	"github.com/zricethezav/gitleaks/v8/report"
 )
 
@@ -31,6 +32,13 @@ func (d *Detector) DetectReader(r io.Reader, bufSize int) ([]report.Finding, err
 			if d.Verbose {
 				printFinding(finding, d.NoColor)
 			}
+			if d.OnFinding != nil {
+				d.OnFinding(finding)
+			}
+			if d.Stats != nil {
+				d.Stats.addFinding(finding.RuleID)
+			}
+			metrics.Findings.Inc(finding.RuleID, finding.Severity)
 		}
 	}
 
@@ -0,0 +1,102 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+// RuleTestFailure is a rule test input that did not behave as expected.
+type RuleTestFailure struct {
+	RuleID string
+
+	// Test is the index of the test in the rule's tests.
+	Test int
+
+	Input   string
+	Message string
+}
+
+func (f RuleTestFailure) String() string {
+	return fmt.Sprintf("rule %s: test %d: %s: %q", f.RuleID, f.Test+1, f.Message, f.Input)
+}
+
+// RuleTestResult summarizes a rule test run.
+type RuleTestResult struct {
+	// Rules is the number of rules that have tests.
+	Rules int
+
+	// Cases is the number of inputs that were tested.
+	Cases int
+
+	Failures []RuleTestFailure
+}
+
+// RunRuleTests runs the tests of every rule in cfg. Each rule is run on its
+// own together with the global allowlist, so tests do not depend on other
+// rules.
+func RunRuleTests(cfg config.Config) RuleTestResult {
+	var result RuleTestResult
+	for _, rule := range cfg.OrderedRules() {
+		if len(rule.Tests) == 0 {
+			continue
+		}
+		result.Rules++
+
+		var keywords []string
+		for _, k := range rule.Keywords {
+			keywords = append(keywords, strings.ToLower(k))
+		}
+		d := NewDetector(config.Config{
+			Rules:      map[string]config.Rule{rule.RuleID: rule},
+			Allowlists: cfg.Allowlists,
+			Keywords:   keywords,
+		})
+
+		for i, test := range rule.Tests {
+			fail := func(input string, format string, args ...interface{}) {
+				result.Failures = append(result.Failures, RuleTestFailure{
+					RuleID:  rule.RuleID,
+					Test:    i,
+					Input:   input,
+					Message: fmt.Sprintf(format, args...),
+				})
+			}
+			for _, tp := range test.TruePositives {
+				result.Cases++
+				findings := d.Detect(Fragment{Raw: tp, FilePath: test.Path})
+				if len(findings) == 0 {
+					fail(tp, "true positive was not detected")
+					continue
+				}
+				if test.Secret == "" {
+					continue
+				}
+				var secrets []string
+				for _, f := range findings {
+					secrets = append(secrets, f.Secret)
+				}
+				if !contains(secrets, test.Secret) {
+					fail(tp, "expected secret %q, got %q", test.Secret, strings.Join(secrets, `", "`))
+				}
+			}
+			for _, fp := range test.FalsePositives {
+				result.Cases++
+				if findings := d.Detect(Fragment{Raw: fp, FilePath: test.Path}); len(findings) != 0 {
+					fail(fp, "false positive was detected with secret %q", findings[0].Secret)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
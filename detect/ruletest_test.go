@@ -0,0 +1,49 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+func TestRunRuleTests(t *testing.T) {
+	viper.Reset()
+	viper.AddConfigPath(configPath)
+	viper.SetConfigName("rule_tests")
+	viper.SetConfigType("toml")
+	require.NoError(t, viper.ReadInConfig())
+
+	var vc config.ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+
+	result := RunRuleTests(cfg)
+	assert.Equal(t, 3, result.Rules)
+	assert.Equal(t, 6, result.Cases)
+	assert.Equal(t, []RuleTestFailure{
+		{
+			RuleID:  "broken-token",
+			Test:    0,
+			Input:   "token_abcdefgh",
+			Message: `expected secret "token_abcdefgh", got "abcdefgh"`,
+		},
+		{
+			RuleID:  "broken-token",
+			Test:    0,
+			Input:   "token_ABCDEFGH",
+			Message: "true positive was not detected",
+		},
+		{
+			RuleID:  "broken-token",
+			Test:    0,
+			Input:   "token_zyxwvuts",
+			Message: `false positive was detected with secret "zyxwvuts"`,
+		},
+	}, result.Failures)
+	assert.Equal(t, `rule broken-token: test 1: true positive was not detected: "token_ABCDEFGH"`, result.Failures[1].String())
+}
@@ -0,0 +1,173 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Stats collects statistics about a scan that help tuning rules, like the
+// time spent in each rule's regex and how often keywords match. Statistics
+// are only collected when Detector.Stats is set.
+type Stats struct {
+	mu        sync.Mutex
+	bytes     int64
+	fragments int64
+	keywords  map[string]int64
+	rules     map[string]*RuleStats
+}
+
+// StatsReport is a snapshot of the statistics of a scan.
+type StatsReport struct {
+	Bytes     int64
+	Fragments int64
+	Commits   int
+
+	// Keywords are sorted by hits, most frequent first
+	Keywords []KeywordStats
+
+	// Rules are sorted by time spent in their regex, slowest first
+	Rules []RuleStats
+}
+
+// KeywordStats counts the fragments a prefilter keyword was found in.
+type KeywordStats struct {
+	Keyword string
+	Hits    int64
+	HitRate float64
+}
+
+// RuleStats counts the fragments a rule was evaluated against, the time
+// spent in its regex and the findings it reported.
+type RuleStats struct {
+	RuleID       string
+	Fragments    int64
+	RegexSeconds float64
+	Findings     int64
+}
+
+// NewStats creates an empty Stats.
+func NewStats() *Stats {
+	return &Stats{
+		keywords: make(map[string]int64),
+		rules:    make(map[string]*RuleStats),
+	}
+}
+
+func (s *Stats) addFragment(size int, keywords map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bytes += int64(size)
+	s.fragments++
+	for k := range keywords {
+		s.keywords[k]++
+	}
+}
+
+func (s *Stats) addRuleRun(ruleID string, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.rule(ruleID)
+	r.Fragments++
+	r.RegexSeconds += elapsed.Seconds()
+}
+
+func (s *Stats) addFinding(ruleID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rule(ruleID).Findings++
+}
+
+// rule returns the stats of a rule, s.mu must be held.
+func (s *Stats) rule(ruleID string) *RuleStats {
+	r, ok := s.rules[ruleID]
+	if !ok {
+		r = &RuleStats{RuleID: ruleID}
+		s.rules[ruleID] = r
+	}
+	return r
+}
+
+// ScanStats returns the statistics of the scan, nil if statistics are not
+// collected. Rules and keywords of the config that were never evaluated or
+// found are included with zero counts.
+func (d *Detector) ScanStats() *StatsReport {
+	if d.Stats == nil {
+		return nil
+	}
+	s := d.Stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &StatsReport{
+		Bytes:     s.bytes,
+		Fragments: s.fragments,
+		Commits:   d.commits(),
+		Keywords:  []KeywordStats{},
+		Rules:     []RuleStats{},
+	}
+
+	keywords := make(map[string]int64)
+	for _, k := range d.Config.Keywords {
+		keywords[k] = 0
+	}
+	for k, hits := range s.keywords {
+		keywords[k] = hits
+	}
+	for k, hits := range keywords {
+		ks := KeywordStats{Keyword: k, Hits: hits}
+		if s.fragments > 0 {
+			ks.HitRate = float64(hits) / float64(s.fragments)
+		}
+		report.Keywords = append(report.Keywords, ks)
+	}
+	sort.Slice(report.Keywords, func(i, j int) bool {
+		a, b := report.Keywords[i], report.Keywords[j]
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return a.Keyword < b.Keyword
+	})
+
+	for id := range d.Config.Rules {
+		report.Rules = append(report.Rules, RuleStats{RuleID: id})
+		if r, ok := s.rules[id]; ok {
+			report.Rules[len(report.Rules)-1] = *r
+		}
+	}
+	// rules that are only defined by nested configs
+	for id, r := range s.rules {
+		if _, ok := d.Config.Rules[id]; !ok {
+			report.Rules = append(report.Rules, *r)
+		}
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		a, b := report.Rules[i], report.Rules[j]
+		if a.RegexSeconds != b.RegexSeconds {
+			return a.RegexSeconds > b.RegexSeconds
+		}
+		return a.RuleID < b.RuleID
+	})
+	return report
+}
+
+// WriteTable writes the statistics as human readable tables.
+func (r *StatsReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "scanned %d bytes in %d fragments and %d commits\n\n", r.Bytes, r.Fragments, r.Commits)
+
+	fmt.Fprintln(tw, "RULE\tFRAGMENTS\tREGEX TIME\tFINDINGS")
+	for _, rule := range r.Rules {
+		elapsed := time.Duration(rule.RegexSeconds * float64(time.Second))
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\n", rule.RuleID, rule.Fragments, elapsed.Round(time.Microsecond), rule.Findings)
+	}
+
+	fmt.Fprintln(tw, "\nKEYWORD\tHITS\tHIT RATE")
+	for _, k := range r.Keywords {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\n", k.Keyword, k.Hits, k.HitRate*100)
+	}
+	return tw.Flush()
+}
@@ -59,31 +59,20 @@ func shannonEntropy(data string) (entropy float64) {
 	return entropy
 }
 
-// filter will dedupe and redact findings
-func filter(findings []report.Finding, redact uint) []report.Finding {
+// filter will dedupe findings
+func filter(findings []report.Finding) []report.Finding {
 	var retFindings []report.Finding
 	for _, f := range findings {
 		include := true
-		if strings.Contains(strings.ToLower(f.RuleID), "generic") {
-			for _, fPrime := range findings {
-				if f.StartLine == fPrime.StartLine &&
-					f.Commit == fPrime.Commit &&
-					f.RuleID != fPrime.RuleID &&
-					strings.Contains(fPrime.Secret, f.Secret) &&
-					!strings.Contains(strings.ToLower(fPrime.RuleID), "generic") {
-
-					genericMatch := strings.Replace(f.Match, f.Secret, "REDACTED", -1)
-					betterMatch := strings.Replace(fPrime.Match, fPrime.Secret, "REDACTED", -1)
-					log.Trace().Msgf("skipping %s finding (%s), %s rule takes precedence (%s)", f.RuleID, genericMatch, fPrime.RuleID, betterMatch)
-					include = false
-					break
-				}
+		if isGeneric(f) {
+			if fPrime := precedingFinding(f, findings); fPrime != nil {
+				genericMatch := strings.Replace(f.Match, f.Secret, "REDACTED", -1)
+				betterMatch := strings.Replace(fPrime.Match, fPrime.Secret, "REDACTED", -1)
+				log.Trace().Msgf("skipping %s finding (%s), %s rule takes precedence (%s)", f.RuleID, genericMatch, fPrime.RuleID, betterMatch)
+				include = false
 			}
 		}
 
-		if redact > 0 {
-			f.Redact(redact)
-		}
 		if include {
 			retFindings = append(retFindings, f)
 		}
@@ -91,6 +80,26 @@ func filter(findings []report.Finding, redact uint) []report.Finding {
 	return retFindings
 }
 
+func isGeneric(f report.Finding) bool {
+	return strings.Contains(strings.ToLower(f.RuleID), "generic")
+}
+
+// precedingFinding returns the finding of a rule that is not generic which
+// takes precedence over f, a finding of a generic rule, because it found
+// the same secret on the same line. It returns nil if there is none.
+func precedingFinding(f report.Finding, findings []report.Finding) *report.Finding {
+	for i, fPrime := range findings {
+		if f.StartLine == fPrime.StartLine &&
+			f.Commit == fPrime.Commit &&
+			f.RuleID != fPrime.RuleID &&
+			strings.Contains(fPrime.Secret, f.Secret) &&
+			!isGeneric(fPrime) {
+			return &findings[i]
+		}
+	}
+	return nil
+}
+
 func printFinding(f report.Finding, noColor bool) {
 	// trim all whitespace and tabs
 	f.Line = strings.TrimSpace(f.Line)
@@ -158,6 +167,9 @@ func printFinding(f report.Finding, noColor bool) {
 
 	fmt.Printf("%-12s %s\n", "RuleID:", f.RuleID)
 	fmt.Printf("%-12s %f\n", "Entropy:", f.Entropy)
+	if f.Verification != "" {
+		fmt.Printf("%-12s %s\n", "Verified:", f.Verification)
+	}
 	if f.File == "" {
 		fmt.Println("")
 		return
@@ -0,0 +1,36 @@
+package detect
+
+import (
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+// invalidStructureTag is added to findings that fail their rule's validator
+// when the rule is configured with keepInvalid.
+const invalidStructureTag = "invalid-structure"
+
+// Validator checks the structure of a secret without making any network
+// calls. It returns true if the secret is well-formed.
+type Validator = config.Validator
+
+// RegisterValidator makes a validator available to rules under the given
+// name, see config.RegisterValidator.
+func RegisterValidator(name string, v Validator) {
+	config.RegisterValidator(name, v)
+}
+
+// HasValidator reports whether a validator is registered under name.
+func HasValidator(name string) bool {
+	return config.KnownValidator(name)
+}
+
+// validate runs the named validator against secret. Configs with unknown
+// validators are rejected when they are loaded, unknown validators of
+// rules built in code are treated as passing so they do not hide
+// findings.
+func validate(name string, secret string) bool {
+	v, ok := config.LookupValidator(name)
+	if !ok {
+		return true
+	}
+	return v(secret)
+}
@@ -0,0 +1,184 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Verification results recorded on findings.
+const (
+	VerificationActive   = "active"
+	VerificationInactive = "inactive"
+	VerificationError    = "error"
+)
+
+// secretPlaceholder is replaced by the secret in verify request templates.
+const secretPlaceholder = "{{secret}}"
+
+// Verifier checks whether a secret matched by a rule is live.
+type Verifier interface {
+	Verify(rule config.Rule, secret string) (bool, error)
+}
+
+// HTTPVerifier executes the request template defined by a rule's Verify
+// table. Results are cached per rule and secret so that a secret seen in
+// many commits is only checked once.
+type HTTPVerifier struct {
+	// Client is the http client used for requests.
+	Client *http.Client
+
+	// EndpointOverride replaces the scheme and host of every request URL,
+	// which is useful for pointing verification at a local mock.
+	EndpointOverride string
+
+	mu    sync.Mutex
+	cache map[string]*verifyResult
+}
+
+// verifyResult is the result of verifying a secret. done is closed once
+// the result is set, so concurrent verifications of the same secret wait
+// for the first one instead of sending their own request.
+type verifyResult struct {
+	done   chan struct{}
+	active bool
+	err    error
+}
+
+// NewHTTPVerifier creates a verifier with a default http client.
+func NewHTTPVerifier(endpointOverride string) *HTTPVerifier {
+	return &HTTPVerifier{
+		Client:           &http.Client{Timeout: 10 * time.Second},
+		EndpointOverride: endpointOverride,
+		cache:            make(map[string]*verifyResult),
+	}
+}
+
+// Verify sends the rule's request with the secret interpolated and reports
+// whether the response status is one of the expected statuses.
+func (v *HTTPVerifier) Verify(rule config.Rule, secret string) (bool, error) {
+	if rule.Verify == nil {
+		return false, fmt.Errorf("rule %s has no verify request", rule.RuleID)
+	}
+	key := rule.RuleID + ":" + secret
+
+	v.mu.Lock()
+	r, ok := v.cache[key]
+	if !ok {
+		r = &verifyResult{done: make(chan struct{})}
+		v.cache[key] = r
+	}
+	v.mu.Unlock()
+
+	if ok {
+		<-r.done
+	} else {
+		r.active, r.err = v.verify(*rule.Verify, secret)
+		close(r.done)
+	}
+	return r.active, r.err
+}
+
+func (v *HTTPVerifier) verify(tmpl config.Verify, secret string) (bool, error) {
+	reqURL, err := url.Parse(strings.ReplaceAll(tmpl.URL, secretPlaceholder, url.QueryEscape(secret)))
+	if err != nil {
+		return false, err
+	}
+	if v.EndpointOverride != "" {
+		override, err := url.Parse(v.EndpointOverride)
+		if err != nil {
+			return false, err
+		}
+		reqURL.Scheme = override.Scheme
+		reqURL.Host = override.Host
+		reqURL.Path = strings.TrimSuffix(override.Path, "/") + reqURL.Path
+	}
+
+	method := tmpl.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if tmpl.Body != "" {
+		body = strings.NewReader(strings.ReplaceAll(tmpl.Body, secretPlaceholder, secret))
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), reqURL.String(), body)
+	if err != nil {
+		return false, err
+	}
+	for k, val := range tmpl.Headers {
+		req.Header.Set(k, strings.ReplaceAll(val, secretPlaceholder, secret))
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	expected := tmpl.ExpectedStatus
+	if len(expected) == 0 {
+		expected = []int{http.StatusOK}
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyWorkers is the number of concurrent verify requests.
+const verifyWorkers = 8
+
+// verifyFindings verifies the secrets of the findings whose rule defines a
+// verify request, with up to verifyWorkers requests at a time. Findings
+// must not be redacted yet.
+func (d *Detector) verifyFindings(findings []report.Finding) {
+	if d.Verifier == nil {
+		return
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < verifyWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				cfg, _ := d.scanConfig(findings[i].File)
+				findings[i].Verification = d.verify(cfg.Rules[findings[i].RuleID], findings[i].Secret)
+			}
+		}()
+	}
+	for i, f := range findings {
+		cfg, _ := d.scanConfig(f.File)
+		if rule, ok := cfg.Rules[f.RuleID]; ok && rule.Verify != nil {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// verify records the result of verifying a finding's secret.
+func (d *Detector) verify(rule config.Rule, secret string) string {
+	active, err := d.Verifier.Verify(rule, secret)
+	if err != nil {
+		log.Debug().Err(err).Msgf("could not verify secret for rule %s", rule.RuleID)
+		return VerificationError
+	}
+	if active {
+		return VerificationActive
+	}
+	return VerificationInactive
+}
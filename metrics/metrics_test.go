@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	c := &Counter{name: "test_total", help: "Test counter.", labels: []string{"rule"}, values: map[string]float64{}}
+	c.Inc("b")
+	c.Inc(`a"quoted"`)
+	c.Add(2, "b")
+
+	var buf bytes.Buffer
+	require.NoError(t, c.write(&buf))
+	assert.Equal(t, `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{rule="a\"quoted\""} 1
+test_total{rule="b"} 3
+`, buf.String())
+
+	assert.Panics(t, func() { c.Inc() })
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	c := &Counter{name: "test_total", help: "Test counter.", values: map[string]float64{}}
+
+	var buf bytes.Buffer
+	require.NoError(t, c.write(&buf))
+	assert.Contains(t, buf.String(), "test_total 0\n")
+}
+
+func TestHistogram(t *testing.T) {
+	h := &Histogram{name: "test_seconds", help: "Test histogram.", buckets: []float64{1, 10}, counts: make([]uint64, 2)}
+	h.Observe(0.5)
+	h.Observe(5)
+	h.Observe(50)
+
+	var buf bytes.Buffer
+	require.NoError(t, h.write(&buf))
+	assert.Equal(t, `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="1"} 1
+test_seconds_bucket{le="10"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 55.5
+test_seconds_count 3
+`, buf.String())
+}
+
+func TestWriteTextfile(t *testing.T) {
+	GitErrors.Inc()
+	path := filepath.Join(t.TempDir(), "gitleaks.prom")
+	require.NoError(t, WriteTextfile(path))
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(got), "# TYPE gitleaks_scans_total counter\n")
+	assert.Contains(t, string(got), "gitleaks_git_errors_total 1\n")
+
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "temporary file was not removed")
+}
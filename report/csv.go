@@ -1,7 +1,9 @@
 package report
 
 import (
+	"bytes"
 	"encoding/csv"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
@@ -60,3 +62,70 @@ func writeCsv(f []Finding, w io.WriteCloser) error {
 	cw.Flush()
 	return cw.Error()
 }
+
+// readCsv reads findings from a csv report. Columns are matched by their
+// header so reports written by older versions can be read as well.
+func readCsv(data []byte) ([]Finding, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []Finding{}, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	if _, ok := columns["RuleID"]; !ok {
+		return nil, fmt.Errorf("missing RuleID column")
+	}
+
+	findings := make([]Finding, 0, len(records)-1)
+	for n, record := range records[1:] {
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		number := func(name string) int {
+			if err != nil {
+				return 0
+			}
+			var v int
+			if s := value(name); s != "" {
+				if v, err = strconv.Atoi(s); err != nil {
+					err = fmt.Errorf("row %d: invalid %s %q", n+2, name, s)
+				}
+			}
+			return v
+		}
+		f := Finding{
+			RuleID:      value("RuleID"),
+			Commit:      value("Commit"),
+			File:        value("File"),
+			SymlinkFile: value("SymlinkFile"),
+			Secret:      value("Secret"),
+			Match:       value("Match"),
+			StartLine:   number("StartLine"),
+			EndLine:     number("EndLine"),
+			StartColumn: number("StartColumn"),
+			EndColumn:   number("EndColumn"),
+			Author:      value("Author"),
+			Message:     value("Message"),
+			Date:        value("Date"),
+			Email:       value("Email"),
+			Fingerprint: value("Fingerprint"),
+			Tags:        strings.Fields(value("Tags")),
+		}
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
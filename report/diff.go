@@ -0,0 +1,52 @@
+package report
+
+// Diff is the result of comparing the findings of two reports.
+type Diff struct {
+	// New contains findings of the new report that are not in the old one.
+	New []Finding
+
+	// Fixed contains findings of the old report that are not in the new one.
+	Fixed []Finding
+
+	// Unchanged contains findings of the new report that are also in the
+	// old one.
+	Unchanged []Finding
+}
+
+// DiffFindings compares the findings of two reports by their BaselineKey,
+// so findings that only moved to another line or commit are unchanged. A
+// secret found more often in the new report than in the old one is new as
+// many times as it was added.
+func DiffFindings(old []Finding, new []Finding) Diff {
+	remaining := make(map[string]int, len(old))
+	for _, f := range old {
+		remaining[f.BaselineKey()]++
+	}
+
+	diff := Diff{
+		New:       []Finding{},
+		Fixed:     []Finding{},
+		Unchanged: []Finding{},
+	}
+	for _, f := range new {
+		key := f.BaselineKey()
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Unchanged = append(diff.Unchanged, f)
+		} else {
+			diff.New = append(diff.New, f)
+		}
+	}
+	// the last occurrences of a key in the old report are the fixed ones
+	for i := len(old) - 1; i >= 0; i-- {
+		key := old[i].BaselineKey()
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Fixed = append(diff.Fixed, old[i])
+		}
+	}
+	for i, j := 0, len(diff.Fixed)-1; i < j; i, j = i+1, j-1 {
+		diff.Fixed[i], diff.Fixed[j] = diff.Fixed[j], diff.Fixed[i]
+	}
+	return diff
+}
@@ -1,6 +1,8 @@
 package report
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"math"
 	"strings"
 )
@@ -41,6 +43,46 @@ type Finding struct {
 
 	// unique identifier
 	Fingerprint string
+
+	// Severity is the severity of the rule that was matched, empty if the
+	// rule does not define one.
+	Severity string `json:",omitempty"`
+
+	// Verification is the result of checking whether the secret is
+	// live, empty if the secret was not verified.
+	Verification string `json:",omitempty"`
+
+	// SecretHash is the hex encoded SHA-256 of the secret, set by
+	// HashSecret. The hash of a short or guessable secret reveals it, so
+	// it is only recorded when asked for.
+	SecretHash string `json:",omitempty"`
+}
+
+// SecretSHA256 returns the hex encoded SHA-256 of the unredacted secret.
+func (f *Finding) SecretSHA256() string {
+	if f.SecretHash != "" {
+		return f.SecretHash
+	}
+	return hashSecret(f.Secret)
+}
+
+// HashSecret records the SHA-256 of the secret in SecretHash, so the
+// finding can still be matched by secret once it is redacted.
+func (f *Finding) HashSecret() {
+	if f.SecretHash == "" {
+		f.SecretHash = hashSecret(f.Secret)
+	}
+}
+
+// BaselineKey returns a key identifying the finding independently of its
+// location in the file, made of the rule ID, file and secret hash.
+func (f *Finding) BaselineKey() string {
+	return f.RuleID + ":" + f.File + ":" + f.SecretSHA256()
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
 }
 
 // Redact removes sensitive information from a finding.
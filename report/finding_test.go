@@ -82,3 +82,21 @@ func TestMaskSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretSHA256(t *testing.T) {
+	f := Finding{Match: "line containing secret", Secret: "secret"}
+	want := "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+	assert.Equal(t, want, f.SecretSHA256())
+
+	// redacting does not record the hash
+	redacted := f
+	redacted.Redact(100)
+	assert.Empty(t, redacted.SecretHash)
+
+	// the hash of the original secret is kept after redaction
+	f.HashSecret()
+	f.Redact(100)
+	assert.Equal(t, "REDACTED", f.Secret)
+	assert.Equal(t, want, f.SecretHash)
+	assert.Equal(t, want, f.SecretSHA256())
+}
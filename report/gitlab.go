@@ -0,0 +1,182 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// gitlabSchemaVersion is the version of the GitLab secret detection report
+// schema that writeGitlab implements.
+const gitlabSchemaVersion = "15.0.6"
+
+// gitlabTimeLayout is the time format used by GitLab security reports.
+const gitlabTimeLayout = "2006-01-02T15:04:05"
+
+// gitlabNoCommit is the commit sha reported for findings outside of git
+// history, like GitLab's own secret detection analyzer does.
+const gitlabNoCommit = "0000000"
+
+// timeNow returns the current time, replaced in tests.
+var timeNow = time.Now
+
+// gitlabSeverities maps lowercase rule severities to the severities accepted
+// by the GitLab schema.
+var gitlabSeverities = map[string]string{
+	"critical": "Critical",
+	"high":     "High",
+	"medium":   "Medium",
+	"low":      "Low",
+	"info":     "Info",
+	"unknown":  "Unknown",
+}
+
+func writeGitlab(findings []Finding, w io.WriteCloser) error {
+	now := timeNow().UTC().Format(gitlabTimeLayout)
+	scanner := GitlabScanner{
+		ID:      driver,
+		Name:    "Gitleaks",
+		URL:     "https://github.com/gitleaks/gitleaks",
+		Vendor:  GitlabVendor{Name: "Gitleaks"},
+		Version: strings.TrimPrefix(version, "v"),
+	}
+	report := GitlabReport{
+		Version:         gitlabSchemaVersion,
+		Vulnerabilities: getGitlabVulnerabilities(findings),
+		Scan: GitlabScan{
+			Analyzer:  scanner,
+			Scanner:   scanner,
+			Type:      "secret_detection",
+			StartTime: now,
+			EndTime:   now,
+			Status:    "success",
+		},
+	}
+	defer w.Close()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(report)
+}
+
+func getGitlabVulnerabilities(findings []Finding) []GitlabVulnerability {
+	vulnerabilities := []GitlabVulnerability{}
+	for _, f := range findings {
+		commit := GitlabCommit{
+			Author:  f.Author,
+			Date:    f.Date,
+			Message: f.Message,
+			Sha:     f.Commit,
+		}
+		if commit.Sha == "" {
+			commit.Sha = gitlabNoCommit
+		}
+		name := f.Description
+		if name == "" {
+			name = f.RuleID
+		}
+		vulnerabilities = append(vulnerabilities, GitlabVulnerability{
+			ID:          gitlabID(f),
+			Name:        name,
+			Description: messageText(f),
+			Severity:    gitlabSeverity(f.Severity),
+			Location: GitlabLocation{
+				File:      f.File,
+				Commit:    commit,
+				StartLine: f.StartLine,
+				EndLine:   f.EndLine,
+			},
+			Identifiers: []GitlabIdentifier{
+				{
+					Type:  "gitleaks_rule_id",
+					Name:  fmt.Sprintf("Gitleaks rule ID %s", f.RuleID),
+					Value: f.RuleID,
+				},
+			},
+			RawSourceCodeExtract: f.Secret,
+		})
+	}
+	return vulnerabilities
+}
+
+// gitlabID derives a stable UUID formatted identifier from the location of
+// a finding so the same finding keeps its id across reports.
+func gitlabID(f Finding) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%s:%d:%d:%d:%d",
+		f.RuleID, f.File, f.Commit, f.StartLine, f.EndLine, f.StartColumn, f.EndColumn)))
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// gitlabSeverity maps a rule severity onto the GitLab schema. Findings of
+// rules without a severity are critical.
+func gitlabSeverity(severity string) string {
+	if severity == "" {
+		return "Critical"
+	}
+	if s, ok := gitlabSeverities[strings.ToLower(severity)]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+type GitlabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []GitlabVulnerability `json:"vulnerabilities"`
+	Scan            GitlabScan            `json:"scan"`
+}
+
+type GitlabVulnerability struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	Description          string             `json:"description"`
+	Severity             string             `json:"severity"`
+	Location             GitlabLocation     `json:"location"`
+	Identifiers          []GitlabIdentifier `json:"identifiers"`
+	RawSourceCodeExtract string             `json:"raw_source_code_extract"`
+}
+
+type GitlabLocation struct {
+	File      string       `json:"file"`
+	Commit    GitlabCommit `json:"commit"`
+	StartLine int          `json:"start_line"`
+	EndLine   int          `json:"end_line"`
+}
+
+type GitlabCommit struct {
+	Author  string `json:"author,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Message string `json:"message,omitempty"`
+	Sha     string `json:"sha"`
+}
+
+type GitlabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type GitlabScan struct {
+	Analyzer  GitlabScanner `json:"analyzer"`
+	Scanner   GitlabScanner `json:"scanner"`
+	Type      string        `json:"type"`
+	StartTime string        `json:"start_time"`
+	EndTime   string        `json:"end_time"`
+	Status    string        `json:"status"`
+}
+
+type GitlabScanner struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	URL     string       `json:"url"`
+	Vendor  GitlabVendor `json:"vendor"`
+	Version string       `json:"version"`
+}
+
+type GitlabVendor struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGitlab(t *testing.T) {
+	tests := []struct {
+		findings       []Finding
+		testReportName string
+		expected       string
+	}{
+		{
+			testReportName: "simple",
+			expected:       filepath.Join(expectPath, "report", "gitlab_simple.json"),
+			findings: []Finding{
+				{
+					Description: "A test rule",
+					RuleID:      "test-rule",
+					Match:       "line containing secret",
+					Secret:      "a secret",
+					StartLine:   1,
+					EndLine:     2,
+					StartColumn: 1,
+					EndColumn:   2,
+					Message:     "opps",
+					File:        "auth.py",
+					Commit:      "0000000000000000",
+					Author:      "John Doe",
+					Email:       "johndoe@gmail.com",
+					Date:        "10-19-2003",
+					Tags:        []string{"tag1", "tag2", "tag3"},
+				},
+				{
+					RuleID:    "test-rule-high",
+					Secret:    "another secret",
+					StartLine: 3,
+					EndLine:   3,
+					File:      "config.py",
+					Severity:  "high",
+				},
+			}},
+		{
+			testReportName: "empty",
+			expected:       filepath.Join(expectPath, "report", "gitlab_empty.json"),
+			findings:       []Finding{},
+		},
+	}
+
+	timeNow = func() time.Time {
+		return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	defer func() { timeNow = time.Now }()
+
+	for _, test := range tests {
+		t.Run(test.testReportName, func(t *testing.T) {
+			tmpfile, err := os.Create(filepath.Join(t.TempDir(), test.testReportName+".json"))
+			require.NoError(t, err)
+			err = writeGitlab(test.findings, tmpfile)
+			require.NoError(t, err)
+			assert.FileExists(t, tmpfile.Name())
+			got, err := os.ReadFile(tmpfile.Name())
+			require.NoError(t, err)
+			want, err := os.ReadFile(test.expected)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestGitlabSeverity(t *testing.T) {
+	assert.Equal(t, "Critical", gitlabSeverity(""))
+	assert.Equal(t, "High", gitlabSeverity("HIGH"))
+	assert.Equal(t, "Info", gitlabSeverity("info"))
+	assert.Equal(t, "Unknown", gitlabSeverity("urgent"))
+}
@@ -0,0 +1,147 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+var htmlReport = template.Must(template.New("html").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"shortSha": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+}).Parse(htmlTemplate))
+
+// htmlData is the value the html report template is executed with.
+type htmlData struct {
+	Version string
+
+	// RepositoryURL is the web page of the scanned repository commits
+	// link to, commits link to the commit table if it is empty.
+	RepositoryURL string
+
+	Findings   []htmlFinding
+	Tags       []string
+	Severities []string
+	Commits    []Finding
+}
+
+// htmlFinding is a finding with its line split around the secret so the
+// secret can be highlighted.
+type htmlFinding struct {
+	Finding
+	Before string
+	Secret string
+	After  string
+}
+
+// WriteHtml writes a self-contained html report to reportPath whose
+// commits link to their page in the repository at repositoryURL, see
+// RepositoryURL.
+func WriteHtml(findings []Finding, repositoryURL string, reportPath string) error {
+	file, err := Create(reportPath)
+	if err != nil {
+		return err
+	}
+	return writeHtml(findings, repositoryURL, file)
+}
+
+// RepositoryURL returns the web page of the repository of a git remote
+// URL, like https://github.com/zricethezav/gitleaks for
+// git@github.com:zricethezav/gitleaks.git, or "" if remote is not a URL.
+// Commit pages are found at <url>/commit/<sha> on GitHub, GitLab and
+// Bitbucket.
+func RepositoryURL(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax, user@host:path
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok || strings.Contains(host, "/") {
+			return ""
+		}
+		remote = "ssh://" + host + "/" + path
+	}
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https", "ssh", "git":
+	default:
+		return ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+	scheme := "https"
+	if u.Scheme == "http" {
+		scheme = "http"
+	}
+	return scheme + "://" + u.Hostname() + "/" + path
+}
+
+// writeHtml writes a self-contained html report. Secrets are highlighted in
+// their line, which is already redacted when --redact is set.
+func writeHtml(findings []Finding, repositoryURL string, w io.WriteCloser) error {
+	defer w.Close()
+	data := htmlData{
+		Version:       version,
+		RepositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+		Findings:      []htmlFinding{},
+	}
+
+	tags := make(map[string]bool)
+	severities := make(map[string]bool)
+	commits := make(map[string]bool)
+	for _, f := range findings {
+		data.Findings = append(data.Findings, newHtmlFinding(f))
+		for _, t := range f.Tags {
+			tags[t] = true
+		}
+		if f.Severity != "" {
+			severities[f.Severity] = true
+		}
+		if f.Commit != "" && !commits[f.Commit] {
+			commits[f.Commit] = true
+			data.Commits = append(data.Commits, f)
+		}
+	}
+	data.Tags = sortedKeys(tags)
+	data.Severities = sortedKeys(severities)
+
+	return htmlReport.Execute(w, data)
+}
+
+func newHtmlFinding(f Finding) htmlFinding {
+	line := strings.TrimRight(f.Line, "\r\n")
+	if line == "" {
+		line = f.Match
+	}
+	hf := htmlFinding{Finding: f, Before: line}
+	if i := strings.Index(line, f.Secret); f.Secret != "" && i != -1 {
+		hf.Before = line[:i]
+		hf.Secret = f.Secret
+		hf.After = line[i+len(f.Secret):]
+	}
+	return hf
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 )
@@ -15,3 +16,52 @@ func writeJson(findings []Finding, w io.WriteCloser) error {
 	encoder.SetIndent("", " ")
 	return encoder.Encode(findings)
 }
+
+func readJson(data []byte) ([]Finding, error) {
+	var findings []Finding
+	if err := json.Unmarshal(data, &findings); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+// JsonlWriter writes findings as JSON Lines, one finding per line, so they
+// can be written as soon as they are found.
+type JsonlWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJsonlWriter creates a JsonlWriter writing to w.
+func NewJsonlWriter(w io.Writer) *JsonlWriter {
+	return &JsonlWriter{encoder: json.NewEncoder(w)}
+}
+
+// Write writes a single finding followed by a newline.
+func (j *JsonlWriter) Write(f Finding) error {
+	return j.encoder.Encode(f)
+}
+
+func readJsonl(data []byte) ([]Finding, error) {
+	findings := []Finding{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var f Finding
+		if err := decoder.Decode(&f); err == io.EOF {
+			return findings, nil
+		} else if err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+}
+
+func writeJsonl(findings []Finding, w io.WriteCloser) error {
+	defer w.Close()
+	jw := NewJsonlWriter(w)
+	for _, f := range findings {
+		if err := jw.Write(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
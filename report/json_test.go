@@ -66,3 +66,58 @@ func TestWriteJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteJsonl(t *testing.T) {
+	tests := []struct {
+		findings       []Finding
+		testReportName string
+		expected       string
+	}{
+		{
+			testReportName: "simple",
+			expected:       filepath.Join(expectPath, "report", "jsonl_simple.jsonl"),
+			findings: []Finding{
+				{
+					RuleID:    "test-rule",
+					Match:     "line containing secret",
+					Secret:    "a secret",
+					StartLine: 1,
+					EndLine:   2,
+					File:      "auth.py",
+					Tags:      []string{},
+				},
+				{
+					RuleID:    "test-rule",
+					Match:     "another secret",
+					Secret:    "another secret",
+					StartLine: 4,
+					EndLine:   4,
+					File:      "auth.py",
+					Tags:      []string{"tag1"},
+				},
+			}},
+		{
+			testReportName: "empty",
+			expected:       filepath.Join(expectPath, "report", "jsonl_empty.jsonl"),
+			findings:       []Finding{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testReportName, func(t *testing.T) {
+			tmpfile, err := os.Create(filepath.Join(t.TempDir(), test.testReportName+".jsonl"))
+			require.NoError(t, err)
+			err = writeJsonl(test.findings, tmpfile)
+			require.NoError(t, err)
+			got, err := os.ReadFile(tmpfile.Name())
+			require.NoError(t, err)
+			want, err := os.ReadFile(test.expected)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			// jsonl reports can be read back
+			findings, err := Read(got)
+			require.NoError(t, err)
+			assert.Equal(t, test.findings, findings)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package report
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -13,22 +16,118 @@ const (
 	CWE_DESCRIPTION = "Use of Hard-coded Credentials"
 )
 
+// StdoutPath is the report path that writes reports to stdout.
+const StdoutPath = "-"
+
+// Create creates the file at reportPath, or returns stdout if reportPath
+// is StdoutPath. Closing stdout is a no-op.
+func Create(reportPath string) (io.WriteCloser, error) {
+	if reportPath == StdoutPath {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(reportPath)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// Formats are the supported report formats. Template reports are written
+// by WriteTemplate, the others by Write.
+var Formats = []string{"json", "jsonl", "csv", "junit", "sarif", "gitlab", "html", "template"}
+
+// formatExtensions are the file extensions Write accepts in place of a
+// format.
+var formatExtensions = map[string]string{
+	".json":  "json",
+	".jsonl": "jsonl",
+	".csv":   "csv",
+	".xml":   "junit",
+	".sarif": "sarif",
+	".html":  "html",
+}
+
+// Format returns the report format given by name, a format or a file
+// extension, or an error if it is not supported.
+func Format(name string) (string, error) {
+	format := strings.ToLower(name)
+	if f, ok := formatExtensions[format]; ok {
+		return f, nil
+	}
+	for _, f := range Formats {
+		if f == format {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown report format %q, expected one of %s", name, strings.Join(Formats, ", "))
+}
+
+// Write writes the findings to reportPath in the format given by ext, a
+// format name or a file extension. Template reports are written by
+// WriteTemplate.
 func Write(findings []Finding, cfg config.Config, ext string, reportPath string) error {
-	file, err := os.Create(reportPath)
+	format, err := Format(ext)
 	if err != nil {
 		return err
 	}
-	ext = strings.ToLower(ext)
-	switch ext {
-	case ".json", "json":
-		err = writeJson(findings, file)
-	case ".csv", "csv":
-		err = writeCsv(findings, file)
-	case ".xml", "junit":
-		err = writeJunit(findings, file)
-	case ".sarif", "sarif":
-		err = writeSarif(cfg, findings, file)
+	var write func(io.WriteCloser) error
+	switch format {
+	case "json":
+		write = func(w io.WriteCloser) error { return writeJson(findings, w) }
+	case "jsonl":
+		write = func(w io.WriteCloser) error { return writeJsonl(findings, w) }
+	case "csv":
+		write = func(w io.WriteCloser) error { return writeCsv(findings, w) }
+	case "junit":
+		write = func(w io.WriteCloser) error { return writeJunit(findings, w) }
+	case "sarif":
+		write = func(w io.WriteCloser) error { return writeSarif(cfg, findings, w) }
+	case "gitlab":
+		write = func(w io.WriteCloser) error { return writeGitlab(findings, w) }
+	case "html":
+		write = func(w io.WriteCloser) error { return writeHtml(findings, "", w) }
+	default:
+		return fmt.Errorf("%s reports are written by WriteTemplate", format)
 	}
 
-	return err
+	file, err := Create(reportPath)
+	if err != nil {
+		return err
+	}
+	return write(file)
+}
+
+// Read reads the findings of a report. The format of the report is
+// detected from its content, json, jsonl, csv and sarif reports are
+// supported.
+func Read(data []byte) ([]Finding, error) {
+	findings, _, err := ReadFormat(data)
+	return findings, err
+}
+
+// ReadFormat reads the findings of a report like Read and also returns the
+// detected format of the report.
+func ReadFormat(data []byte) ([]Finding, string, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		// csv reports without findings are empty
+		return []Finding{}, "csv", nil
+	case trimmed[0] == '[':
+		findings, err := readJson(data)
+		return findings, "json", err
+	case trimmed[0] == '{':
+		// sarif reports are a single object, jsonl reports one per line
+		if findings, err := readSarif(data); err == nil {
+			return findings, "sarif", nil
+		}
+		findings, err := readJsonl(data)
+		return findings, "jsonl", err
+	case bytes.HasPrefix(trimmed, []byte("RuleID,")):
+		findings, err := readCsv(data)
+		return findings, "csv", err
+	}
+	return nil, "", fmt.Errorf("unknown report format")
 }
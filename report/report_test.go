@@ -18,9 +18,9 @@ const (
 
 func TestReport(t *testing.T) {
 	tests := []struct {
-		findings  []Finding
-		ext       string
-		wantEmpty bool
+		findings []Finding
+		ext      string
+		wantErr  bool
 	}{
 		{
 			ext: "json",
@@ -45,7 +45,17 @@ func TestReport(t *testing.T) {
 					RuleID: "test-rule",
 				},
 			},
-			wantEmpty: true,
+			wantErr: true,
+		},
+		{
+			// template reports are written by WriteTemplate
+			ext: "template",
+			findings: []Finding{
+				{
+					RuleID: "test-rule",
+				},
+			},
+			wantErr: true,
 		},
 		{
 			ext: ".csv",
@@ -87,6 +97,14 @@ func TestReport(t *testing.T) {
 				},
 			},
 		},
+		{
+			ext: "gitlab",
+			findings: []Finding{
+				{
+					RuleID: "test-rule",
+				},
+			},
+		},
 		// {
 		// 	ext: "SARIF",
 		// 	findings: []Finding{
@@ -102,15 +120,120 @@ func TestReport(t *testing.T) {
 			tmpfile, err := os.Create(filepath.Join(t.TempDir(), strconv.Itoa(i)+test.ext))
 			require.NoError(t, err)
 			err = Write(test.findings, config.Config{}, test.ext, tmpfile.Name())
-			require.NoError(t, err)
-			got, err := os.ReadFile(tmpfile.Name())
-			require.NoError(t, err)
-			assert.FileExists(t, tmpfile.Name())
-			if test.wantEmpty {
+			got, readErr := os.ReadFile(tmpfile.Name())
+			require.NoError(t, readErr)
+			if test.wantErr {
+				assert.Error(t, err)
 				assert.Empty(t, got)
 				return
 			}
+			require.NoError(t, err)
 			assert.NotEmpty(t, got)
 		})
 	}
 }
+
+func TestFormat(t *testing.T) {
+	for name, want := range map[string]string{"json": "json", "SARIF": "sarif", ".xml": "junit", "template": "template"} {
+		format, err := Format(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, format)
+	}
+	_, err := Format("xml")
+	assert.EqualError(t, err, `unknown report format "xml", expected one of json, jsonl, csv, junit, sarif, gitlab, html, template`)
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   string
+		expected []Finding
+		wantErr  bool
+	}{
+		{
+			name:   "json",
+			report: filepath.Join(expectPath, "report", "json_simple.json"),
+			expected: []Finding{
+				{
+					RuleID:      "test-rule",
+					Match:       "line containing secret",
+					Secret:      "a secret",
+					StartLine:   1,
+					EndLine:     2,
+					StartColumn: 1,
+					EndColumn:   2,
+					Message:     "opps",
+					File:        "auth.py",
+					Commit:      "0000000000000000",
+					Author:      "John Doe",
+					Email:       "johndoe@gmail.com",
+					Date:        "10-19-2003",
+					Tags:        []string{},
+				},
+			},
+		},
+		{
+			name:   "csv",
+			report: filepath.Join(expectPath, "report", "csv_simple.csv"),
+			expected: []Finding{
+				{
+					RuleID:      "test-rule",
+					Match:       "line containing secret",
+					Secret:      "a secret",
+					StartLine:   1,
+					EndLine:     2,
+					StartColumn: 1,
+					EndColumn:   2,
+					Message:     "opps",
+					File:        "auth.py",
+					Commit:      "0000000000000000",
+					Author:      "John Doe",
+					Email:       "johndoe@gmail.com",
+					Date:        "10-19-2003",
+					Fingerprint: "fingerprint",
+					Tags:        []string{"tag1", "tag2", "tag3"},
+				},
+			},
+		},
+		{
+			name:   "sarif",
+			report: filepath.Join(expectPath, "report", "sarif_simple.sarif"),
+			expected: []Finding{
+				{
+					RuleID:      "test-rule",
+					Secret:      "a secret",
+					StartLine:   1,
+					EndLine:     2,
+					StartColumn: 1,
+					EndColumn:   2,
+					Message:     "opps",
+					File:        "auth.py",
+					Commit:      "0000000000000000",
+					Author:      "John Doe",
+					Email:       "johndoe@gmail.com",
+					Date:        "10-19-2003",
+					Tags:        []string{"tag1", "tag2", "tag3"},
+				},
+			},
+		},
+		{
+			name:    "junit",
+			report:  filepath.Join(expectPath, "report", "junit_simple.xml"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.report)
+			require.NoError(t, err)
+			findings, err := Read(data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, findings)
+		})
+	}
+}
@@ -77,6 +77,49 @@ func getRules(cfg config.Config) []Rules {
 	return rules
 }
 
+// readSarif reads findings from a sarif report. Sarif reports don't record
+// the match, entropy or fingerprint of findings so those are left empty.
+func readSarif(data []byte) ([]Finding, error) {
+	var sarif Sarif
+	if err := json.Unmarshal(data, &sarif); err != nil {
+		return nil, err
+	}
+	if sarif.Version == "" {
+		return nil, fmt.Errorf("missing sarif version")
+	}
+
+	findings := []Finding{}
+	for _, run := range sarif.Runs {
+		descriptions := make(map[string]string)
+		for _, rule := range run.Tool.Driver.Rules {
+			descriptions[rule.ID] = rule.Name
+		}
+		for _, r := range run.Results {
+			f := Finding{
+				RuleID:      r.RuleId,
+				Description: descriptions[r.RuleId],
+				Commit:      r.PartialFingerPrints.CommitSha,
+				Email:       r.PartialFingerPrints.Email,
+				Author:      r.PartialFingerPrints.Author,
+				Date:        r.PartialFingerPrints.Date,
+				Message:     r.PartialFingerPrints.CommitMessage,
+				Tags:        r.Properties.Tags,
+			}
+			if len(r.Locations) != 0 {
+				loc := r.Locations[0].PhysicalLocation
+				f.File = loc.ArtifactLocation.URI
+				f.StartLine = loc.Region.StartLine
+				f.EndLine = loc.Region.EndLine
+				f.StartColumn = loc.Region.StartColumn
+				f.EndColumn = loc.Region.EndColumn
+				f.Secret = loc.Region.Snippet.Text
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
 func messageText(f Finding) string {
 	if f.Commit == "" {
 		return fmt.Sprintf("%s has detected secret for file %s.", f.RuleID, f.File)
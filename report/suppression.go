@@ -0,0 +1,36 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Suppression is a finding that was not reported because of an inline
+// gitleaks:allow directive or an allowlist with a description.
+// Suppressions are kept so that they can be audited.
+type Suppression struct {
+	Finding
+
+	// Directive is the marker that suppressed the finding, either
+	// gitleaks:allow, gitleaks:allow-start or allowlist.
+	Directive string
+
+	// Reason is the reason given by the directive or the description of
+	// the allowlist, if any.
+	Reason string
+}
+
+// WriteSuppressions writes suppressions as a JSON array to reportPath.
+func WriteSuppressions(suppressions []Suppression, reportPath string) error {
+	if len(suppressions) == 0 {
+		suppressions = []Suppression{}
+	}
+	file, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(suppressions)
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"unicode/utf8"
+)
+
+// FindingGroup is a set of findings sharing a rule or file, returned by the
+// groupByRule and groupByFile template functions.
+type FindingGroup struct {
+	Key      string
+	Findings []Finding
+}
+
+// templateData is the value templates are executed with.
+type templateData struct {
+	Findings []Finding
+}
+
+// templateFuncs are the functions available to report templates.
+var templateFuncs = template.FuncMap{
+	// redact replaces a secret with REDACTED
+	"redact": func(secret string) string {
+		return "REDACTED"
+	},
+	// mask keeps the first (100 - percent)% of a secret, ex: {{ mask 50 .Secret }}
+	"mask": func(percent uint, secret string) string {
+		return maskSecret(secret, percent)
+	},
+	// truncate shortens a string to n characters, ex: {{ truncate 80 .Line }}
+	"truncate": func(n int, s string) string {
+		if n < 0 || utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n]) + "..."
+	},
+	// json encodes a value as json, strings are quoted and escaped
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"groupByRule": func(findings []Finding) []FindingGroup {
+		return groupFindings(findings, func(f Finding) string { return f.RuleID })
+	},
+	"groupByFile": func(findings []Finding) []FindingGroup {
+		return groupFindings(findings, func(f Finding) string { return f.File })
+	},
+}
+
+// WriteTemplate renders findings with the text/template at templatePath and
+// writes the result to reportPath. The template is executed with a value
+// whose Findings field holds the findings.
+func WriteTemplate(findings []Finding, templatePath string, reportPath string) error {
+	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(templateFuncs).ParseFiles(templatePath)
+	if err != nil {
+		return fmt.Errorf("could not parse report template: %w", err)
+	}
+	file, err := Create(reportPath)
+	if err != nil {
+		return err
+	}
+	return writeTemplate(tmpl, findings, file)
+}
+
+func writeTemplate(tmpl *template.Template, findings []Finding, w io.WriteCloser) error {
+	defer w.Close()
+	if findings == nil {
+		findings = []Finding{}
+	}
+	return tmpl.Execute(w, templateData{Findings: findings})
+}
+
+// groupFindings groups findings by key, groups are sorted by key and keep
+// the order of their findings.
+func groupFindings(findings []Finding, key func(Finding) string) []FindingGroup {
+	index := make(map[string]int)
+	var groups []FindingGroup
+	for _, f := range findings {
+		k := key(f)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, FindingGroup{Key: k})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
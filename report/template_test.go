@@ -0,0 +1,78 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templatePath = "../testdata/report/"
+
+func TestWriteTemplate(t *testing.T) {
+	tests := []struct {
+		findings       []Finding
+		testReportName string
+		template       string
+		expected       string
+		wantErr        bool
+	}{
+		{
+			testReportName: "markdown",
+			template:       filepath.Join(templatePath, "markdown.tmpl"),
+			expected:       filepath.Join(expectPath, "report", "template_markdown.md"),
+			findings: []Finding{
+				{
+					RuleID:    "test-rule",
+					Match:     "line containing secret",
+					Secret:    "a secret",
+					StartLine: 1,
+					File:      "auth.py",
+				},
+				{
+					RuleID:    "another-rule",
+					Match:     "token",
+					Secret:    "token",
+					StartLine: 4,
+					File:      "main.go",
+				},
+				{
+					RuleID:    "test-rule",
+					Match:     "another secret",
+					Secret:    "another secret",
+					StartLine: 7,
+					File:      "auth.py",
+				},
+			},
+		},
+		{
+			testReportName: "empty",
+			template:       filepath.Join(templatePath, "markdown.tmpl"),
+			expected:       filepath.Join(expectPath, "report", "template_empty.md"),
+		},
+		{
+			testReportName: "missing template",
+			template:       filepath.Join(templatePath, "does_not_exist.tmpl"),
+			wantErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testReportName, func(t *testing.T) {
+			reportPath := filepath.Join(t.TempDir(), "report.md")
+			err := WriteTemplate(test.findings, test.template, reportPath)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got, err := os.ReadFile(reportPath)
+			require.NoError(t, err)
+			want, err := os.ReadFile(test.expected)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/gitleaks/go-gitdiff/gitdiff"
 	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/metrics"
 )
 
 var quotedOptPattern = regexp.MustCompile(`^(?:"[^"]+"|'[^']+')$`)
@@ -120,6 +122,25 @@ func NewGitDiffCmd(source string, staged bool) (*GitCmd, error) {
 	}, nil
 }
 
+// GitFiles returns the files git tracks in the working tree of the
+// repository at source, relative to source. Ignored and untracked files,
+// like installed dependencies, are not listed.
+func GitFiles(source string) ([]string, error) {
+	cmd := exec.Command("git", "-C", filepath.Clean(source), "ls-files", "-z")
+	log.Debug().Msgf("executing: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, filepath.FromSlash(f))
+		}
+	}
+	return files, nil
+}
+
 // DiffFilesCh returns a channel with *gitdiff.File.
 func (c *GitCmd) DiffFilesCh() <-chan *gitdiff.File {
 	return c.diffFilesCh
@@ -170,6 +191,7 @@ func listenForStdErr(stderr io.ReadCloser, errCh chan<- error) {
 			log.Warn().Msg(scanner.Text())
 		} else {
 			log.Error().Msgf("[git] %s", scanner.Text())
+			metrics.GitErrors.Inc()
 			errEncountered = true
 		}
 	}
@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// ContextLine is a line of the file of a finding.
+type ContextLine struct {
+	Number int
+	Text   string
+
+	// Finding is true for the lines of the finding itself.
+	Finding bool
+}
+
+// FindingContext returns the lines of the finding with up to n lines
+// before and after it. The file is read from the commit of the finding in
+// the git repository at source, or from disk if the finding has no commit.
+func FindingContext(finding report.Finding, source string, n int) ([]ContextLine, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if finding.Commit != "" {
+		cmd := exec.Command("git", "-C", source, "show", finding.Commit+":"+filepath.ToSlash(finding.File))
+		if data, err = cmd.Output(); err != nil {
+			return nil, fmt.Errorf("could not read %s in commit %s: %w", finding.File, finding.Commit, err)
+		}
+	} else {
+		data, err = os.ReadFile(finding.File)
+		if errors.Is(err, os.ErrNotExist) {
+			data, err = os.ReadFile(filepath.Join(source, finding.File))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	// EndLine includes the newline a regex may match after the secret,
+	// the lines of the match are the lines of the finding
+	endLine := finding.StartLine + strings.Count(finding.Match, "\n")
+	if finding.StartLine < 1 || finding.StartLine > len(lines) {
+		return nil, fmt.Errorf("%s has no line %d, it may have changed since the scan", finding.File, finding.StartLine)
+	}
+
+	first := finding.StartLine - n
+	if first < 1 {
+		first = 1
+	}
+	last := endLine + n
+	if last > len(lines) {
+		last = len(lines)
+	}
+	var context []ContextLine
+	for i := first; i <= last; i++ {
+		context = append(context, ContextLine{
+			Number:  i,
+			Text:    lines[i-1],
+			Finding: i >= finding.StartLine && i <= endLine,
+		})
+	}
+	return context, nil
+}
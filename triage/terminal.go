@@ -0,0 +1,145 @@
+package triage
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"unicode/utf8"
+)
+
+// key is a key pressed by the reviewer: a printable character, or the name
+// of a special key.
+type key string
+
+const (
+	keyEnter     key = "enter"
+	keyEsc       key = "esc"
+	keyBackspace key = "backspace"
+	keyLeft      key = "left"
+	keyRight     key = "right"
+	keyCtrlC     key = "ctrl+c"
+)
+
+// printable returns true if the key is a character that can be typed into
+// a reason.
+func (k key) printable() bool {
+	return utf8.RuneCountInString(string(k)) == 1
+}
+
+// parseKeys parses the bytes read from a terminal in raw mode.
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch {
+		case len(b) >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O'):
+			// escape sequence: parameter bytes followed by a final byte
+			i := 2
+			for i < len(b) && b[i] >= 0x30 && b[i] <= 0x3f {
+				i++
+			}
+			if i < len(b) {
+				switch b[i] {
+				case 'C':
+					keys = append(keys, keyRight)
+				case 'D':
+					keys = append(keys, keyLeft)
+				}
+				i++
+			}
+			b = b[i:]
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, keyEsc)
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keyEnter)
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keyBackspace)
+		case b[0] == 0x03:
+			keys = append(keys, keyCtrlC)
+		case b[0] >= 0x20:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				keys = append(keys, key(string(r)))
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// terminal is the reviewer's terminal in raw mode, showing the alternate
+// screen.
+type terminal struct {
+	in      *os.File
+	out     *os.File
+	resized chan os.Signal
+	reset   func() error
+}
+
+// openTerminal switches the terminal of stdin and stdout to raw mode and
+// the alternate screen.
+func openTerminal() (*terminal, error) {
+	if _, _, err := terminalSize(os.Stdout); err != nil {
+		return nil, fmt.Errorf("triage needs an interactive terminal: %w", err)
+	}
+	reset, err := makeRaw(os.Stdin, os.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("triage needs an interactive terminal: %w", err)
+	}
+	t := &terminal{
+		in:      os.Stdin,
+		out:     os.Stdout,
+		resized: make(chan os.Signal, 1),
+		reset:   reset,
+	}
+	notifyResize(t.resized)
+	// alternate screen, hidden cursor
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	return t, nil
+}
+
+// close restores the screen and the mode of the terminal.
+func (t *terminal) close() error {
+	signal.Stop(t.resized)
+	fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	return t.reset()
+}
+
+// size returns the width and height of the terminal.
+func (t *terminal) size() (int, int) {
+	width, height, err := terminalSize(t.out)
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// draw replaces the screen with the lines of frame. Lines are overwritten
+// in place rather than clearing the screen first, which would flicker.
+func (t *terminal) draw(frame string) error {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range strings.Split(frame, "\n") {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	_, err := t.out.WriteString(b.String())
+	return err
+}
+
+// readKeys blocks until keys are pressed and returns them.
+func (t *terminal) readKeys() ([]key, error) {
+	buf := make([]byte, 256)
+	n, err := t.in.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeys(buf[:n]), nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package triage
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func makeRaw(*os.File, *os.File) (func() error, error) {
+	return nil, fmt.Errorf("raw mode is not supported on %s", runtime.GOOS)
+}
+
+func terminalSize(*os.File) (int, int, error) {
+	return 0, 0, fmt.Errorf("terminals are not supported on %s", runtime.GOOS)
+}
+
+func notifyResize(chan<- os.Signal) {}
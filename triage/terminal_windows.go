@@ -0,0 +1,49 @@
+package triage
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// makeRaw puts the console of in into raw mode: keys are read one at a
+// time without being echoed, and Ctrl+C is read as a key rather than
+// interrupting gitleaks. Escape sequences are enabled for in and out. It
+// returns a func restoring the previous modes.
+func makeRaw(in *os.File, out *os.File) (func() error, error) {
+	inHandle, outHandle := windows.Handle(in.Fd()), windows.Handle(out.Fd())
+	var inMode, outMode uint32
+	if err := windows.GetConsoleMode(inHandle, &inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(outHandle, &outMode); err != nil {
+		return nil, err
+	}
+	rawIn := inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_PROCESSED_INPUT|windows.ENABLE_LINE_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(inHandle, rawIn); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(outHandle, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		windows.SetConsoleMode(inHandle, inMode)
+		return nil, err
+	}
+	return func() error {
+		if err := windows.SetConsoleMode(inHandle, inMode); err != nil {
+			return err
+		}
+		return windows.SetConsoleMode(outHandle, outMode)
+	}, nil
+}
+
+// terminalSize returns the width and height of the console window of out.
+func terminalSize(out *os.File) (int, int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(out.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
+
+// notifyResize does nothing, consoles have no resize signal. The screen is
+// drawn at the new size on the next key.
+func notifyResize(chan<- os.Signal) {}
@@ -0,0 +1,241 @@
+// Package triage walks a reviewer through the findings of a report and
+// records a decision about each of them. False positives are written to
+// .gitleaksignore and accepted risks to a baseline.
+package triage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// dateLayout is the layout of the date of a decision.
+const dateLayout = "2006-01-02"
+
+// Decision is a reviewer's decision about a finding.
+type Decision string
+
+const (
+	// FalsePositive findings are written to .gitleaksignore.
+	FalsePositive Decision = "false-positive"
+
+	// AcceptedRisk findings are written to the baseline.
+	AcceptedRisk Decision = "accepted-risk"
+
+	// ToFix findings are real secrets that must be removed and
+	// rotated. They are only recorded in the triage state.
+	ToFix Decision = "to-fix"
+)
+
+// Entry is the decision about a single finding.
+type Entry struct {
+	Decision Decision
+	Reason   string `json:",omitempty"`
+	Date     string
+
+	// RuleID and File are informational, entries are keyed by the
+	// finding's fingerprint.
+	RuleID string
+	File   string
+}
+
+// State is the state of triaging the findings of a report. It is saved
+// after every decision so triage can resume where it stopped.
+type State struct {
+	// Decisions are keyed by the fingerprint of the finding.
+	Decisions map[string]Entry
+}
+
+// Load loads the triage state at path. A missing file is an empty
+// state.
+func Load(path string) (*State, error) {
+	t := &State{Decisions: make(map[string]Entry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("could not read triage state %s: %w", path, err)
+	}
+	if t.Decisions == nil {
+		t.Decisions = make(map[string]Entry)
+	}
+	return t, nil
+}
+
+// Save writes the triage state to path.
+func (t *State) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0o600)
+}
+
+// Decide records the decision about the finding.
+func (t *State) Decide(finding report.Finding, decision Decision, reason string) {
+	t.Decisions[fingerprint(finding)] = Entry{
+		Decision: decision,
+		Reason:   reason,
+		Date:     time.Now().UTC().Format(dateLayout),
+		RuleID:   finding.RuleID,
+		File:     finding.File,
+	}
+}
+
+// Lookup returns the decision about the finding, if one was made.
+func (t *State) Lookup(finding report.Finding) (Entry, bool) {
+	entry, ok := t.Decisions[fingerprint(finding)]
+	return entry, ok
+}
+
+// Next returns the index of the first finding from index from on that has
+// no decision, or len(findings) if all of them have one.
+func (t *State) Next(findings []report.Finding, from int) int {
+	for i := from; i < len(findings); i++ {
+		if _, ok := t.Lookup(findings[i]); !ok {
+			return i
+		}
+	}
+	return len(findings)
+}
+
+// WriteGitleaksIgnore updates the .gitleaksignore file at path with the
+// decisions about the findings: false positives are added by fingerprint
+// and entries of findings with another decision are removed, so changing
+// a decision is reflected. Other entries and comments are kept as is.
+func (t *State) WriteGitleaksIgnore(path string, findings []report.Finding) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var (
+		buf     bytes.Buffer
+		present = make(map[string]bool)
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if fp, err := detect.IgnoreFingerprint(trimmed); err == nil && fp != "" {
+				if d, ok := t.Decisions[fp]; ok && d.Decision != FalsePositive {
+					continue
+				}
+				present[fp] = true
+			}
+		}
+		buf.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, f := range findings {
+		fp := fingerprint(f)
+		d, ok := t.Decisions[fp]
+		if !ok || d.Decision != FalsePositive || present[fp] {
+			continue
+		}
+		present[fp] = true
+		reason := "false positive"
+		if d.Reason != "" {
+			// quoted values can not contain quotes
+			reason = strings.ReplaceAll(d.Reason, `"`, "'")
+		}
+		fmt.Fprintf(&buf, "fingerprint=%s reason=%q\n", fp, reason)
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o644)
+}
+
+// WriteBaseline updates the baseline at path with the decisions about the
+// findings: accepted risks are added and findings with another decision
+// are removed. Other findings of the baseline are kept. Only json
+// baselines can be updated, csv and sarif reports lack fields of the
+// findings.
+func (t *State) WriteBaseline(path string, findings []report.Finding) error {
+	var baseline []report.Finding
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else if err == nil {
+		var format string
+		if baseline, format, err = report.ReadFormat(data); err != nil {
+			return fmt.Errorf("the format of the file %s is not supported", path)
+		}
+		if format != "json" && len(baseline) > 0 {
+			return fmt.Errorf("baseline %s is a %s report, only json baselines can be updated", path, format)
+		}
+	}
+
+	present := make(map[string]bool)
+	updated := make([]report.Finding, 0, len(baseline))
+	for _, f := range baseline {
+		fp := fingerprint(f)
+		if d, ok := t.Decisions[fp]; ok && d.Decision != AcceptedRisk {
+			continue
+		}
+		present[fp] = true
+		updated = append(updated, f)
+	}
+	for _, f := range findings {
+		fp := fingerprint(f)
+		if d, ok := t.Decisions[fp]; ok && d.Decision == AcceptedRisk && !present[fp] {
+			present[fp] = true
+			updated = append(updated, f)
+		}
+	}
+	data, err = json.MarshalIndent(updated, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0o644)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it to path, so an interrupted write does not leave a truncated file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fingerprint returns the fingerprint of the finding, computed like
+// the detector does for reports that do not contain one.
+func fingerprint(f report.Finding) string {
+	if f.Fingerprint != "" {
+		return f.Fingerprint
+	}
+	if f.Commit != "" {
+		return fmt.Sprintf("%s:%s:%s:%d", f.Commit, f.File, f.RuleID, f.StartLine)
+	}
+	return fmt.Sprintf("%s:%s:%d", f.File, f.RuleID, f.StartLine)
+}
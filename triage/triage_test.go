@@ -0,0 +1,127 @@
+package triage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestState(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "report.triage.json")
+	ignorePath := filepath.Join(dir, ".gitleaksignore")
+	baselinePath := filepath.Join(dir, "baseline.json")
+
+	findings := []report.Finding{
+		{RuleID: "aws-access-key", File: "main.go", StartLine: 3, Secret: "AKIA1", Fingerprint: "main.go:aws-access-key:3"},
+		{RuleID: "generic-api-key", File: "main.go", StartLine: 7, Secret: "abc123"},
+		{RuleID: "github-pat", File: "ci.yml", StartLine: 1, Secret: "ghp_1", Fingerprint: "ci.yml:github-pat:1"},
+	}
+	require.NoError(t, os.WriteFile(ignorePath, []byte("# known\nother.go:aws-access-key:1\n"), 0o600))
+
+	triage, err := Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, 0, triage.Next(findings, 0))
+
+	triage.Decide(findings[0], FalsePositive, `a "test" key`)
+	triage.Decide(findings[1], AcceptedRisk, "")
+	require.NoError(t, triage.Save(statePath))
+	require.NoError(t, triage.WriteGitleaksIgnore(ignorePath, findings))
+	require.NoError(t, triage.WriteBaseline(baselinePath, findings))
+
+	// triage resumes with the finding without a decision
+	triage, err = Load(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, triage.Next(findings, 0))
+	entry, ok := triage.Lookup(findings[1])
+	require.True(t, ok)
+	assert.Equal(t, AcceptedRisk, entry.Decision)
+	assert.Equal(t, "generic-api-key", entry.RuleID)
+
+	ignore, err := os.ReadFile(ignorePath)
+	require.NoError(t, err)
+	assert.Equal(t, "# known\nother.go:aws-access-key:1\nfingerprint=main.go:aws-access-key:3 reason=\"a 'test' key\"\n", string(ignore))
+	baseline, err := detect.LoadBaseline(baselinePath)
+	require.NoError(t, err)
+	require.Len(t, baseline, 1)
+	assert.Equal(t, "generic-api-key", baseline[0].RuleID)
+
+	// the written entry is valid and ignores the finding
+	fp, err := detect.IgnoreFingerprint(`fingerprint=main.go:aws-access-key:3 reason="a 'test' key"`)
+	require.NoError(t, err)
+	assert.Equal(t, findings[0].Fingerprint, fp)
+
+	// changing decisions removes the written entries
+	triage.Decide(findings[0], ToFix, "")
+	triage.Decide(findings[1], FalsePositive, "")
+	require.NoError(t, triage.WriteGitleaksIgnore(ignorePath, findings))
+	require.NoError(t, triage.WriteBaseline(baselinePath, findings))
+	ignore, err = os.ReadFile(ignorePath)
+	require.NoError(t, err)
+	assert.Equal(t, "# known\nother.go:aws-access-key:1\nfingerprint=main.go:generic-api-key:7 reason=\"false positive\"\n", string(ignore))
+	baseline, err = detect.LoadBaseline(baselinePath)
+	require.NoError(t, err)
+	assert.Empty(t, baseline)
+}
+
+func TestWriteBaselineFormat(t *testing.T) {
+	dir := t.TempDir()
+	finding := report.Finding{RuleID: "github-pat", File: "ci.yml", StartLine: 1, Secret: "ghp_1", Fingerprint: "ci.yml:github-pat:1"}
+	triage, err := Load(filepath.Join(dir, "report.triage.json"))
+	require.NoError(t, err)
+	triage.Decide(finding, AcceptedRisk, "")
+
+	// a csv baseline would lose the fields csv has no column for
+	csvPath := filepath.Join(dir, "baseline.csv")
+	csv := "RuleID,Commit,File,SymlinkFile,Secret,Match,StartLine,EndLine,StartColumn,EndColumn,Author,Message,Date,Email,Fingerprint\n" +
+		"aws-access-key,,main.go,,AKIA1,AKIA1,3,3,1,5,,,,,main.go:aws-access-key:3\n"
+	require.NoError(t, os.WriteFile(csvPath, []byte(csv), 0o600))
+	err = triage.WriteBaseline(csvPath, []report.Finding{finding})
+	assert.EqualError(t, err, "baseline "+csvPath+" is a csv report, only json baselines can be updated")
+	data, err := os.ReadFile(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, csv, string(data))
+
+	// files are replaced without leaving temporary files behind
+	jsonPath := filepath.Join(dir, "baseline.json")
+	require.NoError(t, triage.WriteBaseline(jsonPath, []report.Finding{finding}))
+	require.NoError(t, triage.Save(filepath.Join(dir, "report.triage.json")))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"baseline.csv", "baseline.json", "report.triage.json"}, names)
+	baseline, err := detect.LoadBaseline(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, []report.Finding{finding}, baseline)
+}
+
+func TestFindingContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.py")
+	require.NoError(t, os.WriteFile(path, []byte("a\nb\nc\nkey = \"secret\"\nd\ne\nf\n"), 0o600))
+
+	context, err := FindingContext(report.Finding{File: path, StartLine: 4, EndLine: 5, Match: `key = "secret"`}, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []ContextLine{
+		{Number: 2, Text: "b"},
+		{Number: 3, Text: "c"},
+		{Number: 4, Text: `key = "secret"`, Finding: true},
+		{Number: 5, Text: "d"},
+		{Number: 6, Text: "e"},
+	}, context)
+
+	context, err = FindingContext(report.Finding{File: path, StartLine: 1, Match: "a"}, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []ContextLine{{Number: 1, Text: "a", Finding: true}, {Number: 2, Text: "b"}}, context)
+
+	_, err = FindingContext(report.Finding{File: path, StartLine: 20}, "", 1)
+	assert.EqualError(t, err, path+" has no line 20, it may have changed since the scan")
+}
@@ -0,0 +1,390 @@
+package triage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Options configure where Run reads context from and writes decisions to.
+type Options struct {
+	// StatePath is the file the triage state is saved to after every
+	// decision.
+	StatePath string
+
+	// IgnorePath is the .gitleaksignore file false positives are written
+	// to.
+	IgnorePath string
+
+	// BaselinePath is the json baseline accepted risks are written to.
+	BaselinePath string
+
+	// Source is the scanned directory or git repository the lines around
+	// a finding are read from.
+	Source string
+
+	// Context is the number of lines shown before and after a finding.
+	Context int
+
+	// All walks through the findings that have a decision as well.
+	All bool
+
+	// NoColor disables colors.
+	NoColor bool
+}
+
+// Run shows the findings one at a time on the full screen of the terminal
+// and records the reviewer's decisions, until all findings are decided or
+// the reviewer quits.
+func Run(state *State, findings []report.Finding, opts Options) error {
+	u := newUI(state, findings, opts)
+	if u.done {
+		return nil
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.close()
+
+	keys := make(chan []key)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			k, err := term.readKeys()
+			if err != nil {
+				errs <- err
+				return
+			}
+			keys <- k
+		}
+	}()
+
+	for !u.done {
+		if err := term.draw(u.view(term.size())); err != nil {
+			return err
+		}
+		select {
+		case pressed := <-keys:
+			for _, k := range pressed {
+				u.handle(k)
+			}
+		case <-term.resized:
+		case err := <-errs:
+			return err
+		}
+	}
+	return nil
+}
+
+// ui is the state of the triage screen. It is separate from the terminal
+// so it can be driven by keys and rendered to a string.
+type ui struct {
+	state    *State
+	findings []report.Finding
+	opts     Options
+	styles   styles
+
+	// index is the index of the finding shown
+	index int
+
+	// contexts caches the lines around findings by index, they are read
+	// from git and the screen is redrawn on every key
+	contexts map[int]contextResult
+
+	// pending is the decision the reviewer is typing a reason for, it is
+	// empty while browsing the findings
+	pending Decision
+	reason  []rune
+
+	// status is the result of the last decision, shown above the keys
+	status string
+	done   bool
+}
+
+type contextResult struct {
+	lines []ContextLine
+	err   error
+}
+
+type styles struct {
+	header   lipgloss.Style
+	label    lipgloss.Style
+	rule     lipgloss.Style
+	secret   lipgloss.Style
+	marker   lipgloss.Style
+	key      lipgloss.Style
+	err      lipgloss.Style
+	decision map[Decision]lipgloss.Style
+}
+
+func newStyles(noColor bool) styles {
+	if noColor {
+		plain := lipgloss.NewStyle()
+		return styles{
+			header:   lipgloss.NewStyle().Reverse(true),
+			label:    plain,
+			rule:     plain,
+			secret:   plain,
+			marker:   plain,
+			key:      plain,
+			err:      plain,
+			decision: map[Decision]lipgloss.Style{},
+		}
+	}
+	return styles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ffffff")).Background(lipgloss.Color("#5f5f87")),
+		label:  lipgloss.NewStyle().Foreground(lipgloss.Color("#8a8a8a")),
+		rule:   lipgloss.NewStyle().Bold(true),
+		secret: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#f05c07")),
+		marker: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#f05c07")),
+		key:    lipgloss.NewStyle().Bold(true),
+		err:    lipgloss.NewStyle().Foreground(lipgloss.Color("#d70000")),
+		decision: map[Decision]lipgloss.Style{
+			FalsePositive: lipgloss.NewStyle().Foreground(lipgloss.Color("#5f87d7")),
+			AcceptedRisk:  lipgloss.NewStyle().Foreground(lipgloss.Color("#d7af00")),
+			ToFix:         lipgloss.NewStyle().Foreground(lipgloss.Color("#d70000")),
+		},
+	}
+}
+
+func newUI(state *State, findings []report.Finding, opts Options) *ui {
+	u := &ui{
+		state:    state,
+		findings: findings,
+		opts:     opts,
+		styles:   newStyles(opts.NoColor),
+		contexts: make(map[int]contextResult),
+	}
+	u.index = u.next(-1)
+	u.done = u.index >= len(findings)
+	return u
+}
+
+// next returns the index of the finding shown after finding i.
+func (u *ui) next(i int) int {
+	if u.opts.All {
+		return i + 1
+	}
+	return u.state.Next(u.findings, i+1)
+}
+
+// handle updates the screen for a pressed key.
+func (u *ui) handle(k key) {
+	if u.pending != "" {
+		u.handleReason(k)
+		return
+	}
+	u.status = ""
+	switch k {
+	case "f", "a":
+		u.pending = FalsePositive
+		if k == "a" {
+			u.pending = AcceptedRisk
+		}
+		u.reason = nil
+		if entry, ok := u.state.Lookup(u.findings[u.index]); ok && entry.Decision == u.pending {
+			u.reason = []rune(entry.Reason)
+		}
+	case "t":
+		u.decide(ToFix, "")
+	case "s", "n", keyRight:
+		u.advance()
+	case "p", keyLeft:
+		if u.index > 0 {
+			u.index--
+		}
+	case "q", keyCtrlC:
+		u.done = true
+	}
+}
+
+// handleReason edits the reason of the pending decision.
+func (u *ui) handleReason(k key) {
+	switch {
+	case k == keyEnter:
+		decision, reason := u.pending, strings.TrimSpace(string(u.reason))
+		u.pending, u.reason = "", nil
+		u.decide(decision, reason)
+	case k == keyEsc || k == keyCtrlC:
+		u.pending, u.reason = "", nil
+	case k == keyBackspace:
+		if len(u.reason) > 0 {
+			u.reason = u.reason[:len(u.reason)-1]
+		}
+	case k.printable():
+		u.reason = append(u.reason, []rune(string(k))...)
+	}
+}
+
+// decide records the decision about the finding shown, writes it to the
+// files it affects and moves on to the next finding. If a file can not be
+// written the finding stays on screen with the error.
+func (u *ui) decide(decision Decision, reason string) {
+	f := u.findings[u.index]
+	previous, _ := u.state.Lookup(f)
+	u.state.Decide(f, decision, reason)
+	if err := u.state.Save(u.opts.StatePath); err != nil {
+		u.status = fmt.Sprintf("could not save triage state: %s", err)
+		return
+	}
+	if decision == FalsePositive || previous.Decision == FalsePositive {
+		if err := u.state.WriteGitleaksIgnore(u.opts.IgnorePath, u.findings); err != nil {
+			u.status = fmt.Sprintf("could not write %s: %s", u.opts.IgnorePath, err)
+			return
+		}
+	}
+	if decision == AcceptedRisk || previous.Decision == AcceptedRisk {
+		if err := u.state.WriteBaseline(u.opts.BaselinePath, u.findings); err != nil {
+			u.status = fmt.Sprintf("could not write %s: %s", u.opts.BaselinePath, err)
+			return
+		}
+	}
+	u.advance()
+}
+
+// advance moves on to the next finding, triage is done after the last one.
+func (u *ui) advance() {
+	if next := u.next(u.index); next < len(u.findings) {
+		u.index = next
+	} else {
+		u.done = true
+	}
+}
+
+func (u *ui) context(i int) contextResult {
+	c, ok := u.contexts[i]
+	if !ok {
+		c.lines, c.err = FindingContext(u.findings[i], u.opts.Source, u.opts.Context)
+		u.contexts[i] = c
+	}
+	return c
+}
+
+// view renders the screen as height lines of at most width columns: a
+// header, the finding and the lines around it, the status of the last
+// decision and the keys.
+func (u *ui) view(width, height int) string {
+	f := u.findings[u.index]
+	s := u.styles
+
+	counts := make(map[Decision]int)
+	for _, finding := range u.findings {
+		if entry, ok := u.state.Lookup(finding); ok {
+			counts[entry.Decision]++
+		}
+	}
+	left := fmt.Sprintf(" gitleaks triage  %d/%d", u.index+1, len(u.findings))
+	right := fmt.Sprintf("%d false positive · %d accepted risk · %d to fix ",
+		counts[FalsePositive], counts[AcceptedRisk], counts[ToFix])
+	header := left + "  " + right
+	if pad := width - runewidth.StringWidth(left) - runewidth.StringWidth(right); pad >= 2 {
+		header = left + strings.Repeat(" ", pad) + right
+	}
+	header = s.header.Render(fit(header, width))
+
+	body := []string{
+		"",
+		" " + s.rule.Render(fit(f.RuleID, width-1)) + s.label.Render(fit(" "+f.Description, width-1-runewidth.StringWidth(f.RuleID))),
+	}
+	field := func(name string, value string, style lipgloss.Style) {
+		if value != "" {
+			body = append(body, s.label.Render(fmt.Sprintf(" %-10s", name))+style.Render(fit(value, width-11)))
+		}
+	}
+	field("File", fmt.Sprintf("%s:%d", f.File, f.StartLine), lipgloss.NewStyle())
+	field("Commit", f.Commit, lipgloss.NewStyle())
+	author := f.Author
+	if f.Email != "" {
+		author += " <" + f.Email + ">"
+	}
+	field("Author", author, lipgloss.NewStyle())
+	field("Date", f.Date, lipgloss.NewStyle())
+	field("Severity", f.Severity, lipgloss.NewStyle())
+	field("Secret", f.Secret, s.secret)
+	if entry, ok := u.state.Lookup(f); ok {
+		decision := fmt.Sprintf("%s on %s", entry.Decision, entry.Date)
+		if entry.Reason != "" {
+			decision += ": " + entry.Reason
+		}
+		field("Decision", decision, s.decision[entry.Decision])
+	}
+	body = append(body, "")
+
+	context := u.context(u.index)
+	if context.err != nil {
+		body = append(body, s.err.Render(fit(" no context: "+context.err.Error(), width)))
+	}
+	for _, line := range context.lines {
+		marker := "  "
+		if line.Finding {
+			marker = s.marker.Render(" >")
+		}
+		number := s.label.Render(fmt.Sprintf(" %5d │ ", line.Number))
+		text := fit(strings.ReplaceAll(line.Text, "\t", "    "), width-10)
+		if line.Finding && f.Secret != "" && f.Secret != "REDACTED" {
+			text = strings.ReplaceAll(text, f.Secret, s.secret.Render(f.Secret))
+		}
+		body = append(body, marker+number+text)
+	}
+
+	// the header, status and keys take three lines
+	if rows := height - 3; len(body) > rows {
+		if rows < 1 {
+			rows = 1
+		}
+		body = append(body[:rows-1], s.label.Render(fit(" …", width)))
+	}
+	for len(body) < height-3 {
+		body = append(body, "")
+	}
+
+	status := ""
+	if u.status != "" {
+		status = s.err.Render(fit(" "+u.status, width))
+	}
+
+	var keys string
+	if u.pending != "" {
+		// long reasons scroll so the end being typed stays visible
+		prompt, reason := fmt.Sprintf(" reason for %s: ", u.pending), u.reason
+		for len(reason) > 0 && runewidth.StringWidth(prompt+string(reason)) >= width {
+			reason = reason[1:]
+		}
+		prompt = fit(prompt+string(reason), width-1) + "█"
+		keys = prompt + s.label.Render(fit("   enter save · esc cancel", width-runewidth.StringWidth(prompt)))
+	} else {
+		var plain, styled []string
+		for _, k := range [][2]string{
+			{"f", "false positive"},
+			{"a", "accepted risk"},
+			{"t", "to fix"},
+			{"←/p", "previous"},
+			{"→/s", "skip"},
+			{"q", "quit"},
+		} {
+			plain = append(plain, k[0]+" "+k[1])
+			styled = append(styled, s.key.Render(k[0])+" "+s.label.Render(k[1]))
+		}
+		keys = " " + strings.Join(styled, "  ")
+		if runewidth.StringWidth(" "+strings.Join(plain, "  ")) > width {
+			keys = fit(" f/a/t decide  ←/→ move  q quit", width)
+		}
+	}
+
+	lines := append([]string{header}, body...)
+	return strings.Join(append(lines, status, keys), "\n")
+}
+
+// fit truncates text to width columns.
+func fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(text, width, "…")
+}
@@ -0,0 +1,147 @@
+package triage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		keys  []key
+	}{
+		{input: "f", keys: []key{"f"}},
+		{input: "rotated\r", keys: []key{"r", "o", "t", "a", "t", "e", "d", keyEnter}},
+		{input: "\x1b[C\x1b[D\x1bOC", keys: []key{keyRight, keyLeft, keyRight}},
+		{input: "\x1b", keys: []key{keyEsc}},
+		{input: "\x1b[3~a", keys: []key{"a"}},
+		{input: "\x7f\x08\x03", keys: []key{keyBackspace, keyBackspace, keyCtrlC}},
+		{input: "é", keys: []key{"é"}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.keys, parseKeys([]byte(test.input)), "%q", test.input)
+	}
+}
+
+func TestUI(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(source, []byte("package main\n\nconst key = \"AKIA1\"\n\nfunc main() {}\n"), 0o600))
+	opts := Options{
+		StatePath:    filepath.Join(dir, "report.triage.json"),
+		IgnorePath:   filepath.Join(dir, ".gitleaksignore"),
+		BaselinePath: filepath.Join(dir, "baseline.json"),
+		Context:      1,
+		NoColor:      true,
+	}
+	findings := []report.Finding{
+		{RuleID: "aws-access-key", Description: "AWS Access Key", File: source, StartLine: 3, Match: "AKIA1", Secret: "AKIA1", Fingerprint: "main.go:aws-access-key:3"},
+		{RuleID: "generic-api-key", File: source, StartLine: 5, Match: "main", Secret: "main", Fingerprint: "main.go:generic-api-key:5"},
+		{RuleID: "github-pat", File: filepath.Join(dir, "missing.yml"), StartLine: 1, Fingerprint: "missing.yml:github-pat:1"},
+	}
+	state, err := Load(opts.StatePath)
+	require.NoError(t, err)
+	u := newUI(state, findings, opts)
+
+	view := u.view(80, 20)
+	lines := strings.Split(view, "\n")
+	require.Len(t, lines, 20)
+	for _, line := range lines {
+		assert.LessOrEqual(t, lipgloss.Width(line), 80, line)
+	}
+	assert.Contains(t, lines[0], "gitleaks triage  1/3")
+	assert.Contains(t, lines[0], "0 false positive · 0 accepted risk · 0 to fix")
+	assert.Contains(t, view, "aws-access-key AWS Access Key")
+	assert.Contains(t, view, "       2 │ ")
+	assert.Contains(t, view, " >     3 │ const key = \"AKIA1\"")
+	assert.Contains(t, lines[19], "f false positive")
+
+	// a false positive with a reason, typing and deleting characters
+	for _, k := range []key{"f", "t", "e", "s", "t", "x", keyBackspace, " ", "k", "e", "y"} {
+		u.handle(k)
+	}
+	assert.Contains(t, u.view(80, 20), "reason for false-positive: test key█")
+	u.handle(keyEnter)
+	assert.Equal(t, 1, u.index)
+	ignore, err := os.ReadFile(opts.IgnorePath)
+	require.NoError(t, err)
+	assert.Equal(t, "fingerprint=main.go:aws-access-key:3 reason=\"test key\"\n", string(ignore))
+
+	// cancelling the reason makes no decision
+	u.handle("a")
+	u.handle("x")
+	u.handle(keyEsc)
+	_, ok := state.Lookup(findings[1])
+	assert.False(t, ok)
+	assert.Equal(t, 1, u.index)
+
+	// going back and changing the decision rewrites .gitleaksignore
+	u.handle(keyLeft)
+	assert.Equal(t, 0, u.index)
+	assert.Contains(t, u.view(80, 20), "Decision  false-positive on ")
+	u.handle("t")
+	ignore, err = os.ReadFile(opts.IgnorePath)
+	require.NoError(t, err)
+	assert.Empty(t, string(ignore))
+
+	// the finding without a decision is next, skipping moves past it
+	assert.Equal(t, 1, u.index)
+	u.handle("s")
+	assert.Equal(t, 2, u.index)
+	assert.Contains(t, u.view(80, 20), "no context: ")
+
+	u.handle("a")
+	u.handle(keyEnter)
+	assert.True(t, u.done)
+	baseline, err := os.ReadFile(opts.BaselinePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(baseline), "missing.yml:github-pat:1")
+
+	// triage resumes with the finding without a decision
+	state, err = Load(opts.StatePath)
+	require.NoError(t, err)
+	u = newUI(state, findings, opts)
+	assert.Equal(t, 1, u.index)
+	u.handle("q")
+	assert.True(t, u.done)
+
+	// with every finding decided there is nothing to show
+	state.Decide(findings[1], ToFix, "")
+	assert.True(t, newUI(state, findings, opts).done)
+	assert.False(t, newUI(state, findings, Options{All: true}).done)
+}
+
+func TestUIView(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(source, []byte(strings.Repeat("x = 1\n", 50)+"key = \"AKIA1\"\n"), 0o600))
+	finding := report.Finding{RuleID: "aws-access-key", File: source, StartLine: 51, Match: "AKIA1", Secret: "AKIA1"}
+	state, err := Load(filepath.Join(dir, "report.triage.json"))
+	require.NoError(t, err)
+
+	// small screens cut the context and the keys to fit
+	u := newUI(state, []report.Finding{finding}, Options{Context: 40, NoColor: true})
+	lines := strings.Split(u.view(30, 10), "\n")
+	require.Len(t, lines, 10)
+	for _, line := range lines {
+		assert.LessOrEqual(t, lipgloss.Width(line), 30, line)
+	}
+	assert.Equal(t, " …", lines[7])
+	assert.Equal(t, " f/a/t decide  ←/→ move  q qu…", lines[9])
+
+	// long reasons scroll to the end being typed
+	u.handle("f")
+	for _, r := range "a reason longer than the screen" {
+		u.handle(key(string(r)))
+	}
+	lines = strings.Split(u.view(40, 10), "\n")
+	assert.Equal(t, " reason for false-positive:  the screen█", lines[9])
+}